@@ -0,0 +1,139 @@
+package ctxlog
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// buildZapLogger builds the logger with zap.Config.Build and returns a function releasing the output sinks.
+// The sinks are opened here and passed to Build through the sink registry, because Build doesn't release them.
+// The level is checked by the handler, so the zap level is not restricted.
+func buildZapLogger(conf zap.Config, o options) (*zap.Logger, func(), error) {
+	registerZapExtensions()
+
+	sink, closeOut, err := zap.Open(conf.OutputPaths...)
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // wrapped by New
+	}
+	errSink, closeErrOut, err := zap.Open(conf.ErrorOutputPaths...)
+	if err != nil {
+		closeOut()
+		return nil, nil, err //nolint:wrapcheck // wrapped by New
+	}
+	closeSinks := func() {
+		closeOut()
+		closeErrOut()
+	}
+
+	conf.OutputPaths = []string{_sinks.add(sink)}
+	conf.ErrorOutputPaths = []string{_sinks.add(errSink)}
+	conf.Encoding = registeredEncoding(conf.Encoding, o)
+	conf.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	zapLogger, err := conf.Build()
+	if err != nil {
+		closeSinks()
+		return nil, nil, err //nolint:wrapcheck // wrapped by New
+	}
+
+	return zapLogger, closeSinks, nil
+}
+
+const (
+	// sinkScheme is the URL scheme of the sinks of the sink registry.
+	sinkScheme = "ctxlog"
+	// encodingPrefix is the prefix of the encodings registered in zap.
+	encodingPrefix = "ctxlog-"
+)
+
+var registerOnce sync.Once //nolint:gochecknoglobals // zap registries are global
+
+// registerZapExtensions registers the encoders of this package and the sink registry in zap.
+func registerZapExtensions() {
+	registerOnce.Do(func() {
+		register := func(encoding string, o options) {
+			_ = zap.RegisterEncoder(registeredEncoding(encoding, o), func(conf zapcore.EncoderConfig) (zapcore.Encoder, error) {
+				return newEncoder(encoding, conf, o), nil
+			})
+		}
+
+		register("json", options{})         //nolint:exhaustruct // no encoder options
+		register(encodingLogfmt, options{}) //nolint:exhaustruct // no encoder options
+		for _, compact := range []bool{false, true} {
+			for _, color := range []ColorMode{ColorNever, ColorAlways} {
+				register(encodingPretty, options{prettyCompact: compact, color: color}) //nolint:exhaustruct // encoder options
+			}
+		}
+
+		_ = zap.RegisterSink(sinkScheme, _sinks.open)
+	})
+}
+
+// registeredEncoding returns the name of the encoding registered in zap for the encoding and the options.
+// The options of the pretty encoder are a part of the name, because zap encoder constructors
+// get only the encoder config.
+func registeredEncoding(encoding string, o options) string {
+	switch encoding {
+	case "console":
+		return encoding
+	case encodingPretty:
+		return encodingPrefix + encoding + "-compact=" + strconv.FormatBool(o.prettyCompact) +
+			"-color=" + strconv.FormatBool(o.useColor())
+	default:
+		return encodingPrefix + encoding
+	}
+}
+
+// _sinks passes the opened sinks to zap.Config.Build.
+var _sinks = &sinkRegistry{} //nolint:gochecknoglobals,exhaustruct // zap registries are global
+
+// sinkRegistry keeps the opened sinks until zap opens them by the URLs returned by add.
+type sinkRegistry struct {
+	mu    sync.Mutex
+	next  uint64
+	sinks map[string]zapcore.WriteSyncer
+}
+
+// add registers the sink and returns its URL.
+func (r *sinkRegistry) add(ws zapcore.WriteSyncer) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sinks == nil {
+		r.sinks = make(map[string]zapcore.WriteSyncer)
+	}
+	r.next++
+	id := strconv.FormatUint(r.next, 10)
+	r.sinks[id] = ws
+
+	return sinkScheme + ":" + id
+}
+
+// open is the zap sink factory of the registry, it removes the sink from the registry.
+func (r *sinkRegistry) open(u *url.URL) (zap.Sink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws, ok := r.sinks[u.Opaque]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q", u.String())
+	}
+	delete(r.sinks, u.Opaque)
+
+	return ownedSink{WriteSyncer: ws}, nil
+}
+
+// ownedSink is a sink released by Logger.Close instead of zap.
+type ownedSink struct {
+	zapcore.WriteSyncer
+}
+
+// Close implements zap.Sink, the sink is released by Logger.Close.
+func (ownedSink) Close() error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package ctxlog
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildZapLogger verifies that the logger is built with the zap config, including its sampling,
+// and writes to the output paths with the encoders of this package.
+func TestBuildZapLogger(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		prefix string
+		lines  int
+	}{
+		{
+			name:   "production JSON with sampling",
+			opts:   []Option{WithEnvType(EnvProduction)},
+			prefix: "{",
+			lines:  101, // the first 100 records and every 100th after them
+		},
+		{
+			name:   "development logfmt",
+			opts:   []Option{WithEnvType(EnvDevelopment), WithFormat(FormatLogfmt)},
+			prefix: "T=",
+			lines:  200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "log")
+			logger, err := New(append(tt.opts, WithOutputPaths(path))...)
+			require.NoError(t, err)
+
+			for range 200 {
+				logger.Info(context.Background(), "message")
+			}
+			require.NoError(t, logger.Close(context.Background()))
+
+			f, err := os.Open(path)
+			require.NoError(t, err)
+			defer f.Close()
+
+			lines := 0
+			for scanner := bufio.NewScanner(f); scanner.Scan(); lines++ {
+				require.Contains(t, scanner.Text(), "message")
+				if lines == 0 {
+					require.True(t, strings.HasPrefix(scanner.Text(), tt.prefix), scanner.Text())
+				}
+			}
+			require.Equal(t, tt.lines, lines)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package ctxlog
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Helper marks the calling function as a logging helper, like testing.TB.Helper.
+// The source and the stack trace of records logged from a helper point at the caller of the helper.
+// Helper can be called any number of times; repeated calls from the same place are cheap.
+func Helper() {
+	var pcs [1]uintptr
+	// skip runtime.Callers and Helper
+	if runtime.Callers(2, pcs[:]) == 0 { //nolint:mnd // see above
+		return
+	}
+
+	_helpers.markCaller(pcs[0])
+}
+
+// RegisterHelperPackages marks all functions of the packages as logging helpers, see Helper.
+// A prefix matches the package with this import path and its subpackages:
+// "github.com/acme/log" matches "github.com/acme/log" and "github.com/acme/log/http",
+// but not "github.com/acme/logutil".
+func RegisterHelperPackages(prefixes ...string) {
+	_helpers.addPackages(prefixes)
+}
+
+// _helpers is the registry of logging helpers.
+var _helpers = &helperRegistry{} //nolint:gochecknoglobals,exhaustruct // singleton like testing.TB.Helper
+
+// helperRegistry holds the functions and packages marked as logging helpers.
+// Lookups are lock-free; the maps are copied on change, which happens once per helper.
+type helperRegistry struct {
+	mu      sync.Mutex
+	enabled atomic.Bool
+	set     atomic.Pointer[helperSet]
+	// callSites are the program counters of the Helper calls that are already registered.
+	callSites atomic.Pointer[map[uintptr]struct{}]
+	// pcs caches whether a program counter belongs to a helper.
+	pcs atomic.Pointer[map[uintptr]bool]
+}
+
+// helperSet is an immutable set of helper functions and packages.
+type helperSet struct {
+	funcs    map[string]struct{}
+	packages []string
+}
+
+// match reports whether the function is a helper.
+func (s *helperSet) match(function string) bool {
+	if s == nil || function == "" {
+		return false
+	}
+
+	if _, ok := s.funcs[function]; ok {
+		return true
+	}
+
+	pkg := packagePath(function)
+	for _, p := range s.packages {
+		if pkg == p || strings.HasPrefix(pkg, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markCaller marks the function of the program counter as a helper.
+func (r *helperRegistry) markCaller(pc uintptr) {
+	if sites := r.callSites.Load(); sites != nil {
+		if _, ok := (*sites)[pc]; ok {
+			return
+		}
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.callSites.Store(copyWith(r.callSites.Load(), pc, struct{}{}))
+
+	old := r.set.Load()
+	if old.match(frame.Function) {
+		return
+	}
+
+	set := &helperSet{funcs: map[string]struct{}{frame.Function: {}}} //nolint:exhaustruct // packages are copied below
+	if old != nil {
+		for f := range old.funcs {
+			set.funcs[f] = struct{}{}
+		}
+		set.packages = old.packages
+	}
+	r.update(set)
+}
+
+// addPackages marks the packages as helpers.
+func (r *helperRegistry) addPackages(prefixes []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	set := &helperSet{funcs: map[string]struct{}{}} //nolint:exhaustruct // packages are copied below
+	if old := r.set.Load(); old != nil {
+		set.funcs = old.funcs
+		set.packages = append(set.packages, old.packages...)
+	}
+	for _, p := range prefixes {
+		if p = strings.TrimSuffix(p, "/"); p != "" {
+			set.packages = append(set.packages, p)
+		}
+	}
+	r.update(set)
+}
+
+// update replaces the helper set and resets the program counter cache. r.mu must be held.
+func (r *helperRegistry) update(set *helperSet) {
+	r.set.Store(set)
+	r.pcs.Store(nil)
+	r.enabled.Store(true)
+}
+
+// isHelper reports whether the program counter belongs to a helper.
+func (r *helperRegistry) isHelper(pc uintptr) bool {
+	if !r.enabled.Load() {
+		return false
+	}
+
+	if pcs := r.pcs.Load(); pcs != nil {
+		if v, ok := (*pcs)[pc]; ok {
+			return v
+		}
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v := r.set.Load().match(frame.Function)
+	r.pcs.Store(copyWith(r.pcs.Load(), pc, v))
+
+	return v
+}
+
+// caller returns the first caller that is not a helper, starting at pc,
+// which must be on the stack of the caller of caller.
+// Helpers can be inlined into their callers, so the frames are matched by function, not by program counter:
+// caller returns the program counter that contains the frame, the frame itself and whether the frame
+// is an inlined caller, which cannot be found by the program counter alone.
+func (r *helperRegistry) caller(pc uintptr) (uintptr, runtime.Frame, bool) {
+	var buf [maxStackFrames]uintptr
+	// skip runtime.Callers and caller
+	stack := buf[:runtime.Callers(2, buf[:])] //nolint:mnd // see above
+
+	if start := slices.Index(stack, pc); start >= 0 {
+		set := r.set.Load()
+		for _, p := range stack[start:] {
+			// the frames of the functions inlined at p, innermost first
+			frames := runtime.CallersFrames([]uintptr{p})
+			for inlined := false; ; inlined = true {
+				frame, more := frames.Next()
+				if !set.match(frame.Function) {
+					return p, frame, inlined
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return pc, frame, false
+}
+
+// copyWith returns a copy of the map with the key set to the value.
+func copyWith[K comparable, V any](old *map[K]V, key K, value V) *map[K]V {
+	var m map[K]V
+	if old != nil {
+		m = make(map[K]V, len(*old)+1)
+		for k, v := range *old {
+			m[k] = v
+		}
+	} else {
+		m = make(map[K]V, 1)
+	}
+	m[key] = value
+
+	return &m
+}
@@ -0,0 +1,76 @@
+package ctxlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/n-r-w/ctxlog"
+	"github.com/n-r-w/ctxlog/internal/helpertest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestRegisterHelperPackages verifies that the source of records logged from the functions
+// of registered packages points at their caller, also if the functions are inlined.
+func TestRegisterHelperPackages(t *testing.T) {
+	t.Parallel()
+
+	ctxlog.RegisterHelperPackages("github.com/n-r-w/ctxlog/internal/helpertest")
+
+	tests := []struct {
+		name string
+		log  func(ctx context.Context) int
+	}{
+		{
+			name: "inlined",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				helpertest.Info(ctx, "helper message")
+				return line + 1
+			},
+		},
+		{
+			name: "not inlined",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				helpertest.InfoNoInline(ctx, "helper message")
+				return line + 1
+			},
+		},
+		{
+			name: "nested",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				helpertest.Nested(ctx, "helper message")
+				return line + 1
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := ctxlog.New(
+				ctxlog.WithFormat(ctxlog.FormatJSON),
+				ctxlog.WithSourcePath(ctxlog.SourcePathModule),
+				ctxlog.WithTesting(t),
+				ctxlog.WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			line := tt.log(ctxlog.ToContext(context.Background(), logger))
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.Equal(t, "caller_package_test.go:"+strconv.Itoa(line), record["source"])
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// infoHelper is a logging helper marked with Helper.
+func infoHelper(ctx context.Context) {
+	Helper()
+	Info(ctx, "helper message")
+}
+
+// nestedHelper is a logging helper that calls another helper.
+func nestedHelper(ctx context.Context) {
+	Helper()
+	infoHelper(ctx)
+}
+
+// slogHelper is a logging helper that uses the slog logger directly.
+func slogHelper(ctx context.Context) {
+	Helper()
+	FromContext(ctx).InfoContext(ctx, "helper message")
+}
+
+// TestHelper verifies that the source of records logged from helpers points at the caller.
+func TestHelper(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		log  func(ctx context.Context) int
+	}{
+		{
+			name: "helper",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				infoHelper(ctx)
+				return line + 1
+			},
+		},
+		{
+			name: "nested helpers",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				nestedHelper(ctx)
+				return line + 1
+			},
+		},
+		{
+			name: "slog logger",
+			log: func(ctx context.Context) int {
+				_, _, line, _ := runtime.Caller(0)
+				slogHelper(ctx)
+				return line + 1
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(
+				WithFormat(FormatJSON),
+				WithSourcePath(SourcePathModule),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			// log twice to check the cached path
+			var line int
+			for range 2 {
+				line = tt.log(ToContext(context.Background(), logger))
+			}
+			require.NoError(t, logger.Sync())
+
+			lines := buffer.Lines()
+			require.Len(t, lines, 2)
+			for _, l := range lines {
+				var record map[string]any
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(l)), &record))
+				require.Equal(t, "caller_test.go:"+strconv.Itoa(line), record["source"])
+			}
+		})
+	}
+}
+
+// TestHelperSet_Match verifies the matching of helper functions and packages.
+func TestHelperSet_Match(t *testing.T) {
+	t.Parallel()
+
+	set := &helperSet{
+		funcs:    map[string]struct{}{"github.com/acme/app.logError": {}},
+		packages: []string{"github.com/acme/log"},
+	}
+
+	tests := []struct {
+		function string
+		want     bool
+	}{
+		{function: "github.com/acme/app.logError", want: true},
+		{function: "github.com/acme/app.logWarn", want: false},
+		{function: "github.com/acme/log.Info", want: true},
+		{function: "github.com/acme/log.(*Logger).Info", want: true},
+		{function: "github.com/acme/log/http.Middleware.func1", want: true},
+		{function: "github.com/acme/logutil.Info", want: false},
+		{function: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, set.match(tt.function))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package ctxlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ColorMode controls colored output of the console and pretty formats.
+type ColorMode int
+
+const (
+	// ColorAuto (default) enables colors if all outputs are terminals.
+	// The NO_COLOR environment variable disables colors and FORCE_COLOR enables them.
+	ColorAuto ColorMode = iota
+
+	// ColorAlways always enables colors.
+	ColorAlways
+
+	// ColorNever always disables colors.
+	ColorNever
+)
+
+// ColorModeFromString returns the ColorMode for a case-insensitive string: "AUTO", "ALWAYS" or "NEVER".
+func ColorModeFromString(s string) (ColorMode, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "AUTO":
+		return ColorAuto, nil
+	case "ALWAYS":
+		return ColorAlways, nil
+	case "NEVER":
+		return ColorNever, nil
+	}
+
+	return ColorAuto, fmt.Errorf("unknown color mode: %q (valid: AUTO, ALWAYS, NEVER)", s)
+}
+
+// String returns the name of the color mode.
+func (m ColorMode) String() string {
+	switch m {
+	case ColorAuto:
+		return "AUTO"
+	case ColorAlways:
+		return "ALWAYS"
+	case ColorNever:
+		return "NEVER"
+	}
+
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+// Set implements flag.Value.
+func (m *ColorMode) Set(s string) error {
+	mode, err := ColorModeFromString(s)
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// useColor reports whether the output should be colored.
+func (o options) useColor() bool {
+	switch o.color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	case ColorAuto:
+	}
+
+	// https://no-color.org
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if v := os.Getenv("FORCE_COLOR"); v != "" && v != "0" && !strings.EqualFold(v, "false") {
+		return true
+	}
+
+	if o.testBuffer != nil {
+		return false
+	}
+
+	paths := o.outputPaths
+	if len(paths) == 0 {
+		paths = []string{"stderr"}
+	}
+	for _, p := range paths {
+		var f *os.File
+		switch p {
+		case "stdout":
+			f = os.Stdout
+		case "stderr":
+			f = os.Stderr
+		default:
+			return false
+		}
+		if !isTerminal(f) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
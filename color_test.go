@@ -0,0 +1,69 @@
+package ctxlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestOptions_UseColor verifies color detection.
+func TestOptions_UseColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       ColorMode
+		noColor    string
+		forceColor string
+		paths      []string
+		want       bool
+	}{
+		{name: "always", mode: ColorAlways, want: true},
+		{name: "always ignores NO_COLOR", mode: ColorAlways, noColor: "1", want: true},
+		{name: "never", mode: ColorNever, forceColor: "1", want: false},
+		{name: "auto with NO_COLOR", mode: ColorAuto, noColor: "1", forceColor: "1", want: false},
+		{name: "auto with FORCE_COLOR", mode: ColorAuto, forceColor: "1", paths: []string{"/tmp/app.log"}, want: true},
+		{name: "auto with FORCE_COLOR=0", mode: ColorAuto, forceColor: "0", paths: []string{"/tmp/app.log"}, want: false},
+		{name: "auto with file output", mode: ColorAuto, paths: []string{"/tmp/app.log"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.forceColor)
+
+			o := options{color: tt.mode, outputPaths: tt.paths} //nolint:exhaustruct // only color options are needed
+			require.Equal(t, tt.want, o.useColor())
+		})
+	}
+}
+
+// TestLogger_WithColor verifies that WithColor controls escape codes in the output.
+func TestLogger_WithColor(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []ColorMode{ColorAlways, ColorNever} {
+		t.Run(mode.String(), func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(
+				WithFormat(FormatPretty),
+				WithColor(mode),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			logger.Info(context.Background(), "color message", "key", "value")
+			require.NoError(t, logger.Sync())
+
+			if mode == ColorAlways {
+				require.Contains(t, buffer.String(), "\x1b[")
+			} else {
+				require.NotContains(t, buffer.String(), "\x1b[")
+			}
+		})
+	}
+}
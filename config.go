@@ -0,0 +1,325 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a declarative logger configuration.
+// It can be loaded from JSON or YAML and embedded into the application configuration.
+// Empty fields keep the defaults of New.
+type Config struct {
+	// Env is the environment mode: DEV, DEVELOPMENT, PROD, PRODUCTION.
+	Env string `json:"env,omitempty" yaml:"env,omitempty"`
+	// Format is the output format: AUTO, CONSOLE, JSON, LOGFMT, PRETTY.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Color is the color mode: AUTO, ALWAYS, NEVER.
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+	// Level is the minimum logging level: DEBUG, INFO, WARN, ERROR.
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Name is the logger name.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Source adds the file name and line number of the call to the log record.
+	Source *bool `json:"source,omitempty" yaml:"source,omitempty"`
+	// TimeLayout is the time layout.
+	TimeLayout string `json:"time_layout,omitempty" yaml:"time_layout,omitempty"`
+	// Sampling configures the sampler. Sampling is disabled if nil.
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Otel enables OpenTelemetry integration.
+	Otel bool `json:"otel,omitempty" yaml:"otel,omitempty"`
+	// Output is the list of output sinks, see WithOutputPaths.
+	Output []string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// SamplingConfig is the sampler configuration, see WithSampler.
+type SamplingConfig struct {
+	Tick       Duration `json:"tick"       yaml:"tick"`
+	First      int      `json:"first"      yaml:"first"`
+	Thereafter int      `json:"thereafter" yaml:"thereafter"`
+}
+
+// Duration is a time.Duration that is encoded as a string like "1s" or "100ms".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Options converts the configuration into logger options.
+func (c Config) Options() ([]Option, error) {
+	var opts []Option
+
+	if c.Env != "" {
+		env, err := EnvTypeFromString(strings.ToUpper(c.Env))
+		if err != nil {
+			return nil, configError("env", err)
+		}
+		opts = append(opts, WithEnvType(env))
+	}
+
+	if c.Format != "" {
+		format, err := FormatFromString(c.Format)
+		if err != nil {
+			return nil, configError("format", err)
+		}
+		opts = append(opts, WithFormat(format))
+	}
+
+	if c.Color != "" {
+		mode, err := ColorModeFromString(c.Color)
+		if err != nil {
+			return nil, configError("color", err)
+		}
+		opts = append(opts, WithColor(mode))
+	}
+
+	if c.Level != "" {
+		level, err := ParseLogLevel(c.Level)
+		if err != nil {
+			return nil, configError("level", err)
+		}
+		opts = append(opts, WithLevel(level))
+	}
+
+	if c.Name != "" {
+		opts = append(opts, WithName(c.Name))
+	}
+
+	if c.Source != nil {
+		opts = append(opts, WithSource(*c.Source))
+	}
+
+	if c.TimeLayout != "" {
+		opts = append(opts, WithTimeLayout(c.TimeLayout))
+	}
+
+	if c.Sampling != nil {
+		if err := c.Sampling.validate(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSampler(time.Duration(c.Sampling.Tick), c.Sampling.First, c.Sampling.Thereafter))
+	}
+
+	if c.Otel {
+		opts = append(opts, WithOtelTracing())
+	}
+
+	if len(c.Output) > 0 {
+		opts = append(opts, WithOutputPaths(c.Output...))
+	}
+
+	return opts, nil
+}
+
+// Build creates a new logger from the configuration.
+// Options passed in opts are applied after the configuration ones.
+// The level of the created logger can be changed with Logger.Reload.
+// If the resolved level is a *slog.LevelVar, it is used as is and Reload changes it.
+func (c Config) Build(opts ...Option) (*Logger, error) {
+	cfgOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload falls back to the level and sampler of opts when the configuration omits them
+	base := options{level: slog.LevelDebug} //nolint:exhaustruct // only the level and sampler are needed
+	for _, opt := range opts {
+		opt(&base)
+	}
+
+	opts = append(cfgOpts, opts...)
+
+	// the level is always dynamic to support Logger.Reload
+	o := options{level: slog.LevelDebug} //nolint:exhaustruct // only the level is needed
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if _, ok := o.level.(*slog.LevelVar); !ok {
+		level := &slog.LevelVar{}
+		level.Set(o.level.Level())
+		opts = append(opts, WithLevel(level))
+	}
+
+	l, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	l.state.baseLevel = base.level.Level()
+	l.state.baseSampler = newSampler(base.samplingTick, base.samplingFirst, base.samplingThereafter)
+
+	return l, nil
+}
+
+// Reload applies the level and sampling settings of the configuration to the logger
+// and all loggers derived from it. Other settings are ignored.
+// If the configuration omits the level or the sampling, the ones the logger was built with are restored:
+// for Config.Build and WatchConfig, the ones set by the options passed to them.
+// The logger must be created by Config.Build or with a *slog.LevelVar passed to WithLevel.
+func (l *Logger) Reload(c Config) error {
+	levelVar, ok := l.opts.level.(*slog.LevelVar)
+	if !ok {
+		return errors.New("logger level is not reloadable")
+	}
+
+	level := l.state.baseLevel
+	if c.Level != "" {
+		var err error
+		if level, err = ParseLogLevel(c.Level); err != nil {
+			return configError("level", err)
+		}
+	}
+
+	s := l.state.baseSampler
+	if c.Sampling != nil {
+		if err := c.Sampling.validate(); err != nil {
+			return err
+		}
+		s = newSampler(time.Duration(c.Sampling.Tick), c.Sampling.First, c.Sampling.Thereafter)
+	}
+
+	levelVar.Set(level)
+	l.state.sampler.Store(s)
+
+	return nil
+}
+
+func (c SamplingConfig) validate() error {
+	if c.Tick <= 0 {
+		return configError("sampling.tick", errors.New("must be positive"))
+	}
+	if c.First < 0 {
+		return configError("sampling.first", errors.New("must not be negative"))
+	}
+	if c.Thereafter < 0 {
+		return configError("sampling.thereafter", errors.New("must not be negative"))
+	}
+	// a sampler without first and thereafter drops every record
+	if c.First == 0 && c.Thereafter == 0 {
+		return configError("sampling.first", errors.New("must be positive when sampling.thereafter is 0"))
+	}
+
+	return nil
+}
+
+// LoadConfig reads the configuration from a JSON (.json) or YAML (.yaml, .yml) file.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	data, err := os.ReadFile(path) //nolint:gosec // path is provided by the application
+	if err != nil {
+		return c, fmt.Errorf("failed to read logger config: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &c)
+	default:
+		return c, fmt.Errorf("unsupported logger config file extension: %q", ext)
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to parse logger config %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// WatchConfig creates a new logger from the configuration file (see LoadConfig)
+// and checks the file for changes every interval. On change, the level and sampling
+// settings are re-applied with Logger.Reload; reload errors are logged by the logger itself.
+// Watching stops when the logger is closed.
+func WatchConfig(path string, interval time.Duration, opts ...Option) (*Logger, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be positive")
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime, err := configModTime(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := c.Build(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	l.state.closers = append(l.state.closers, func() { close(stop) })
+
+	go l.watchConfig(path, interval, modTime, stop)
+
+	return l, nil
+}
+
+func (l *Logger) watchConfig(path string, interval time.Duration, modTime time.Time, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		t, err := configModTime(path)
+		if err != nil {
+			l.Error(ctx, "failed to check logger config", slog.Any("error", err))
+			continue
+		}
+		if t.Equal(modTime) {
+			continue
+		}
+		modTime = t
+
+		c, err := LoadConfig(path)
+		if err == nil {
+			err = l.Reload(c)
+		}
+		if err != nil {
+			l.Error(ctx, "failed to reload logger config", slog.Any("error", err))
+			continue
+		}
+
+		l.Info(ctx, "logger config reloaded", slog.String("path", path))
+	}
+}
+
+func configModTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat logger config: %w", err)
+	}
+	return fi.ModTime(), nil
+}
+
+func configError(field string, err error) error {
+	return fmt.Errorf("invalid logger config field %q: %w", field, err)
+}
@@ -0,0 +1,270 @@
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestLoadConfig verifies loading the configuration from JSON and YAML files.
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	source := false
+	want := Config{
+		Env:        "DEV",
+		Level:      "WARN",
+		Name:       "svc",
+		Source:     &source,
+		TimeLayout: time.RFC3339,
+		Sampling:   &SamplingConfig{Tick: Duration(time.Second), First: 10, Thereafter: 100},
+		Otel:       true,
+		Output:     []string{"stdout"},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "json",
+			file: "log.json",
+			content: `{"env": "DEV", "level": "WARN", "name": "svc", "source": false,
+				"time_layout": "2006-01-02T15:04:05Z07:00",
+				"sampling": {"tick": "1s", "first": 10, "thereafter": 100},
+				"otel": true, "output": ["stdout"]}`,
+		},
+		{
+			name: "yaml",
+			file: "log.yaml",
+			content: `
+env: DEV
+level: WARN
+name: svc
+source: false
+time_layout: "2006-01-02T15:04:05Z07:00"
+sampling:
+  tick: 1s
+  first: 10
+  thereafter: 100
+otel: true
+output: [stdout]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			got, err := LoadConfig(path)
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+// TestConfig_Build verifies that the configuration is applied to the logger.
+func TestConfig_Build(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	cfg := Config{Env: "dev", Level: "info", Name: "cfg_logger"} //nolint:exhaustruct // defaults
+	logger, err := cfg.Build(WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logger.Debug(ctx, "debug message")
+	logger.Info(ctx, "info message")
+
+	// Reload lowers the level
+	require.NoError(t, logger.Reload(Config{Level: "DEBUG"})) //nolint:exhaustruct // defaults
+	logger.Debug(ctx, "reloaded debug message")
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.NotContains(t, output, "\tdebug message")
+	require.Contains(t, output, "info message")
+	require.Contains(t, output, "reloaded debug message")
+	require.Contains(t, output, "cfg_logger")
+}
+
+// TestConfig_BuildLevelVar verifies that a *slog.LevelVar passed to Build keeps controlling the logger.
+func TestConfig_BuildLevelVar(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	level := &slog.LevelVar{}
+	level.Set(slog.LevelWarn)
+
+	cfg := Config{Env: "dev", Level: "debug"} //nolint:exhaustruct // defaults
+	logger, err := cfg.Build(WithTesting(t), WithTestBuffer(buffer), WithLevel(level))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logger.Info(ctx, "info message")
+
+	level.Set(slog.LevelInfo)
+	logger.Info(ctx, "enabled info message")
+
+	// Reload changes the caller's level
+	require.NoError(t, logger.Reload(Config{Level: "ERROR"})) //nolint:exhaustruct // defaults
+	require.Equal(t, slog.LevelError, level.Level())
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.NotContains(t, output, "\tinfo message")
+	require.Contains(t, output, "enabled info message")
+}
+
+// TestConfig_BuildReload verifies that Reload keeps the level and sampler passed to Build
+// when the configuration omits them.
+func TestConfig_BuildReload(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	cfg := Config{Env: "dev", Level: "error"} //nolint:exhaustruct // defaults
+	logger, err := cfg.Build(WithTesting(t), WithTestBuffer(buffer),
+		WithLevel(slog.LevelWarn), WithSampler(time.Minute, 1, 0))
+	require.NoError(t, err)
+
+	require.NoError(t, logger.Reload(Config{Name: "x", Level: "info"})) //nolint:exhaustruct // defaults
+	require.NoError(t, logger.Reload(Config{Name: "x"}))                //nolint:exhaustruct // defaults
+
+	ctx := context.Background()
+	logger.Info(ctx, "info message")
+	for range 3 {
+		logger.Warn(ctx, "sampled message")
+	}
+
+	// a sampler that drops every record is rejected
+	invalid := Config{Sampling: &SamplingConfig{Tick: Duration(time.Second)}} //nolint:exhaustruct // defaults
+	require.Error(t, logger.Reload(invalid))
+	logger.Warn(ctx, "warn message")
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.NotContains(t, output, "info message")
+	require.Equal(t, 1, strings.Count(output, "sampled message"))
+	require.Contains(t, output, "warn message")
+}
+
+// TestConfig_Errors verifies that validation errors name the offending field.
+func TestConfig_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		config    Config
+		wantField string
+	}{
+		{
+			name:      "invalid env",
+			config:    Config{Env: "staging"}, //nolint:exhaustruct // defaults
+			wantField: `"env"`,
+		},
+		{
+			name:      "invalid level",
+			config:    Config{Level: "verbose"}, //nolint:exhaustruct // defaults
+			wantField: `"level"`,
+		},
+		{
+			name:      "invalid sampling tick",
+			config:    Config{Sampling: &SamplingConfig{First: 1}}, //nolint:exhaustruct // defaults
+			wantField: `"sampling.tick"`,
+		},
+		{
+			name:      "sampling tick only",
+			config:    Config{Sampling: &SamplingConfig{Tick: Duration(time.Second)}}, //nolint:exhaustruct // defaults
+			wantField: `"sampling.first"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.config.Build()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantField)
+		})
+	}
+}
+
+// TestLogger_ReloadNotReloadable verifies that Reload requires a dynamic level.
+func TestLogger_ReloadNotReloadable(t *testing.T) {
+	t.Parallel()
+
+	logger := NewTest(t, WithLevel(slog.LevelInfo))
+	require.Error(t, logger.Reload(Config{Level: "DEBUG"})) //nolint:exhaustruct // defaults
+}
+
+// TestWatchConfig verifies that changes of the configuration file are re-applied.
+func TestWatchConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("level: INFO\n"), 0o600))
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := WatchConfig(path, 10*time.Millisecond, WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelInfo, logger.opts.level.Level())
+
+	require.NoError(t, os.WriteFile(path, []byte("level: ERROR\n"), 0o600))
+	// make sure the modification time changes on file systems with coarse timestamps
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	require.Eventually(t, func() bool {
+		return logger.opts.level.Level() == slog.LevelError
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, logger.Close(context.Background()))
+}
+
+// TestLogger_ReloadSampling verifies that sampling settings are re-applied to derived loggers.
+func TestLogger_ReloadSampling(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	cfg := Config{ //nolint:exhaustruct // defaults
+		Env:      "DEV",
+		Sampling: &SamplingConfig{Tick: Duration(time.Minute), First: 1, Thereafter: 0},
+	}
+	logger, err := cfg.Build(WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	child := logger.With("child", true)
+	ctx := context.Background()
+	for range 3 {
+		child.Info(ctx, "sampled message")
+	}
+
+	cfg.Sampling = nil
+	require.NoError(t, logger.Reload(cfg))
+	for range 3 {
+		child.Info(ctx, "unsampled message")
+	}
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.Equal(t, 1, strings.Count(output, "\tsampled message"))
+	require.Equal(t, 3, strings.Count(output, "unsampled message"))
+}
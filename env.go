@@ -0,0 +1,189 @@
+package ctxlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variable names read by NewFromEnv (without prefix).
+const (
+	envVarLevel              = "LOG_LEVEL"
+	envVarEnv                = "LOG_ENV"
+	envVarFormat             = "LOG_FORMAT"
+	envVarColor              = "LOG_COLOR"
+	envVarName               = "LOG_NAME"
+	envVarSource             = "LOG_SOURCE"
+	envVarTimeLayout         = "LOG_TIME_LAYOUT"
+	envVarSamplingTick       = "LOG_SAMPLING_TICK"
+	envVarSamplingFirst      = "LOG_SAMPLING_FIRST"
+	envVarSamplingThereafter = "LOG_SAMPLING_THEREAFTER"
+	envVarOutput             = "LOG_OUTPUT"
+)
+
+// NewFromEnv creates a new logger configured from environment variables.
+// Variable names are prefix + name, e.g. for prefix "APP_" the level is read from APP_LOG_LEVEL.
+// Supported variables:
+//   - LOG_LEVEL: DEBUG, INFO, WARN, ERROR (see ParseLogLevel).
+//   - LOG_ENV: DEV, DEVELOPMENT, PROD, PRODUCTION (see EnvTypeFromString).
+//   - LOG_FORMAT: AUTO, CONSOLE, JSON, LOGFMT, PRETTY (see FormatFromString).
+//   - LOG_COLOR: AUTO, ALWAYS, NEVER (see ColorModeFromString).
+//   - LOG_NAME: logger name.
+//   - LOG_SOURCE: true/false, adds the call source to records.
+//   - LOG_TIME_LAYOUT: time layout, e.g. 2006-01-02T15:04:05Z07:00.
+//   - LOG_SAMPLING_TICK, LOG_SAMPLING_FIRST, LOG_SAMPLING_THEREAFTER: sampler settings (see WithSampler);
+//     the tick requires a positive first or thereafter.
+//   - LOG_OUTPUT: comma-separated output paths (see WithOutputPaths).
+//
+// Unset or empty variables keep the defaults. Options passed in opts are applied after the environment ones.
+func NewFromEnv(prefix string, opts ...Option) (*Logger, error) {
+	envOpts, err := optionsFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(append(envOpts, opts...)...)
+}
+
+func optionsFromEnv(prefix string) ([]Option, error) {
+	var opts []Option
+
+	if v, name, ok := lookupEnv(prefix, envVarLevel); ok {
+		level, err := ParseLogLevel(v)
+		if err != nil {
+			return nil, envError(name, err)
+		}
+		opts = append(opts, WithLevel(level))
+	}
+
+	if v, name, ok := lookupEnv(prefix, envVarEnv); ok {
+		env, err := EnvTypeFromString(strings.ToUpper(v))
+		if err != nil {
+			return nil, envError(name, err)
+		}
+		opts = append(opts, WithEnvType(env))
+	}
+
+	if v, name, ok := lookupEnv(prefix, envVarFormat); ok {
+		format, err := FormatFromString(v)
+		if err != nil {
+			return nil, envError(name, err)
+		}
+		opts = append(opts, WithFormat(format))
+	}
+
+	if v, name, ok := lookupEnv(prefix, envVarColor); ok {
+		mode, err := ColorModeFromString(v)
+		if err != nil {
+			return nil, envError(name, err)
+		}
+		opts = append(opts, WithColor(mode))
+	}
+
+	if v, _, ok := lookupEnv(prefix, envVarName); ok {
+		opts = append(opts, WithName(v))
+	}
+
+	if v, name, ok := lookupEnv(prefix, envVarSource); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, envError(name, err)
+		}
+		opts = append(opts, WithSource(b))
+	}
+
+	if v, _, ok := lookupEnv(prefix, envVarTimeLayout); ok {
+		opts = append(opts, WithTimeLayout(v))
+	}
+
+	samplerOpt, err := samplerFromEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if samplerOpt != nil {
+		opts = append(opts, samplerOpt)
+	}
+
+	if v, _, ok := lookupEnv(prefix, envVarOutput); ok {
+		opts = append(opts, WithOutputPaths(splitList(v)...))
+	}
+
+	return opts, nil
+}
+
+func samplerFromEnv(prefix string) (Option, error) {
+	var (
+		tick              time.Duration
+		first, thereafter int
+		err               error
+	)
+
+	tickStr, tickName, tickOk := lookupEnv(prefix, envVarSamplingTick)
+	if tickOk {
+		if tick, err = time.ParseDuration(tickStr); err != nil {
+			return nil, envError(tickName, err)
+		}
+		if tick <= 0 {
+			return nil, envError(tickName, errors.New("must be positive"))
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		dst  *int
+	}{
+		{name: envVarSamplingFirst, dst: &first},
+		{name: envVarSamplingThereafter, dst: &thereafter},
+	} {
+		s, name, ok := lookupEnv(prefix, v.name)
+		if !ok {
+			continue
+		}
+		if !tickOk {
+			return nil, envError(tickName, fmt.Errorf("required when %s is set", name))
+		}
+		if *v.dst, err = strconv.Atoi(s); err != nil {
+			return nil, envError(name, err)
+		}
+		if *v.dst < 0 {
+			return nil, envError(name, errors.New("must not be negative"))
+		}
+	}
+
+	if !tickOk {
+		return nil, nil //nolint:nilnil // sampler is not configured
+	}
+	// a sampler without first and thereafter drops every record
+	if first == 0 && thereafter == 0 {
+		return nil, envError(prefix+envVarSamplingFirst,
+			fmt.Errorf("must be positive when %s is set and %s is 0", tickName, prefix+envVarSamplingThereafter))
+	}
+
+	return WithSampler(tick, first, thereafter), nil
+}
+
+// lookupEnv returns the trimmed value of the prefixed variable, its full name
+// and whether it is set to a non-empty value.
+func lookupEnv(prefix, name string) (string, string, bool) {
+	fullName := prefix + name
+	v := strings.TrimSpace(os.Getenv(fullName))
+	return v, fullName, v != ""
+}
+
+// splitList splits a comma-separated list, dropping empty elements.
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func envError(name string, err error) error {
+	return fmt.Errorf("invalid environment variable %s: %w", name, err)
+}
@@ -0,0 +1,124 @@
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestNewFromEnv verifies that environment variables are applied to the logger.
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("TEST_LOG_LEVEL", "warn")
+	t.Setenv("TEST_LOG_ENV", "dev")
+	t.Setenv("TEST_LOG_NAME", "env_logger")
+	t.Setenv("TEST_LOG_SOURCE", "false")
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := NewFromEnv("TEST_", WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logger.Info(ctx, "info message")
+	logger.Warn(ctx, "warn message")
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.NotContains(t, output, "info message", "level from environment should filter info")
+	require.Contains(t, output, "warn message")
+	require.Contains(t, output, "env_logger", "output should contain name from environment")
+	require.NotContains(t, output, "env_test.go", "source should be disabled from environment")
+}
+
+// TestOptionsFromEnv verifies parsing of environment variables into options.
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "ERROR")
+	t.Setenv("APP_LOG_ENV", "PROD")
+	t.Setenv("APP_LOG_TIME_LAYOUT", time.Kitchen)
+	t.Setenv("APP_LOG_SAMPLING_TICK", "1s")
+	t.Setenv("APP_LOG_SAMPLING_FIRST", "10")
+	t.Setenv("APP_LOG_SAMPLING_THEREAFTER", "5")
+	t.Setenv("APP_LOG_OUTPUT", "stdout, /tmp/app.log")
+
+	opts, err := optionsFromEnv("APP_")
+	require.NoError(t, err)
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	require.Equal(t, slog.LevelError, o.level)
+	require.Equal(t, EnvProduction, o.env)
+	require.Equal(t, time.Kitchen, o.timeLayout)
+	require.Equal(t, time.Second, o.samplingTick)
+	require.Equal(t, 10, o.samplingFirst)
+	require.Equal(t, 5, o.samplingThereafter)
+	require.Equal(t, []string{"stdout", "/tmp/app.log"}, o.outputPaths)
+}
+
+// TestOptionsFromEnv_Errors verifies that errors name the offending variable.
+func TestOptionsFromEnv_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantVar string
+	}{
+		{
+			name:    "invalid level",
+			env:     map[string]string{"BAD_LOG_LEVEL": "verbose"},
+			wantVar: "BAD_LOG_LEVEL",
+		},
+		{
+			name:    "invalid env type",
+			env:     map[string]string{"BAD_LOG_ENV": "staging"},
+			wantVar: "BAD_LOG_ENV",
+		},
+		{
+			name:    "invalid source",
+			env:     map[string]string{"BAD_LOG_SOURCE": "maybe"},
+			wantVar: "BAD_LOG_SOURCE",
+		},
+		{
+			name:    "invalid sampling tick",
+			env:     map[string]string{"BAD_LOG_SAMPLING_TICK": "often"},
+			wantVar: "BAD_LOG_SAMPLING_TICK",
+		},
+		{
+			name:    "invalid sampling first",
+			env:     map[string]string{"BAD_LOG_SAMPLING_TICK": "1s", "BAD_LOG_SAMPLING_FIRST": "x"},
+			wantVar: "BAD_LOG_SAMPLING_FIRST",
+		},
+		{
+			name:    "sampling tick only",
+			env:     map[string]string{"BAD_LOG_SAMPLING_TICK": "1s"},
+			wantVar: "BAD_LOG_SAMPLING_FIRST",
+		},
+		{
+			name:    "sampling tick with zero values",
+			env:     map[string]string{"BAD_LOG_SAMPLING_TICK": "1s", "BAD_LOG_SAMPLING_FIRST": "0"},
+			wantVar: "BAD_LOG_SAMPLING_FIRST",
+		},
+		{
+			name:    "sampling without tick",
+			env:     map[string]string{"BAD_LOG_SAMPLING_THEREAFTER": "3"},
+			wantVar: "BAD_LOG_SAMPLING_TICK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := NewFromEnv("BAD_")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantVar)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package ctxlog
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/n-r-w/ctxlog/serrors"
+)
+
+// errorKey is the key of the attribute returned by Err.
+const errorKey = "error"
+
+// Err returns an "error" attribute that renders the structure of the error built by serrors.Chain,
+// see serrors.LogValue:
+//   - msg: the error message.
+//   - type: the concrete type of the error.
+//   - field: the field path of an error added to serrors.Collector, if it has one.
+//   - kind: the serrors kind of the error, if it has one.
+//   - chain: the concrete types of the error and the errors unwrapped from it with Unwrap() error,
+//     if it wraps any. The annotations of serrors.WithKind and serrors.With are not included.
+//   - sites: the serrors call site prefixes of the chain without braces, if there are any.
+//   - stack: the stack of the first error of the chain with a StackTrace() []uintptr method.
+//   - branches: the errors of errors.Join or another Unwrap() []error error at the end of the chain,
+//     rendered the same way with the keys "0", "1", ...
+//
+// Err returns an empty attribute, which is ignored by the logger, if err is nil.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+
+	return slog.Any(errorKey, errorValue{err: err})
+}
+
+// BareErrorMode controls how error arguments without a key are logged.
+// slog parses logging arguments as key-value pairs, so an error passed without a key,
+// as in ctxlog.Error(ctx, "failed", err), is logged with the "!BADKEY" key.
+type BareErrorMode int
+
+const (
+	// BareErrorAny (default) logs an error argument without a key in any position as Err(err).
+	BareErrorAny BareErrorMode = iota
+
+	// BareErrorTrailing logs an error argument without a key as Err(err) only if it is the last argument.
+	BareErrorTrailing
+
+	// BareErrorStrict keeps the slog behavior: error arguments without a key are logged with the "!BADKEY" key.
+	BareErrorStrict
+)
+
+// String returns the name of the bare error mode.
+func (m BareErrorMode) String() string {
+	switch m {
+	case BareErrorAny:
+		return "ANY"
+	case BareErrorTrailing:
+		return "TRAILING"
+	case BareErrorStrict:
+		return "STRICT"
+	}
+
+	return fmt.Sprintf("BareErrorMode(%d)", int(m))
+}
+
+// badKey is the key slog uses for arguments that are not key-value pairs.
+const badKey = "!BADKEY"
+
+// bareError returns the error of the attribute at index i of n attributes,
+// if it is an error argument without a key that the mode converts.
+func (m BareErrorMode) bareError(a slog.Attr, i, n int) (error, bool) {
+	if a.Key != badKey {
+		return nil, false
+	}
+
+	switch m {
+	case BareErrorAny:
+	case BareErrorTrailing:
+		if i != n-1 {
+			return nil, false
+		}
+	case BareErrorStrict:
+		return nil, false
+	}
+
+	err := attrError(a)
+	return err, err != nil
+}
+
+// convertAttrs replaces error arguments without a key with Err attributes.
+// It returns attrs if there is nothing to replace.
+func (m BareErrorMode) convertAttrs(attrs []slog.Attr) []slog.Attr {
+	var converted []slog.Attr
+	for i, a := range attrs {
+		err, ok := m.bareError(a, i, len(attrs))
+		if !ok {
+			continue
+		}
+		if converted == nil {
+			converted = slices.Clone(attrs)
+		}
+		converted[i] = Err(err)
+	}
+
+	if converted == nil {
+		return attrs
+	}
+	return converted
+}
+
+// convertRecord replaces error arguments without a key with Err attributes.
+// It returns r if there is nothing to replace.
+func (m BareErrorMode) convertRecord(r slog.Record) slog.Record {
+	if m == BareErrorStrict {
+		return r
+	}
+
+	n := r.NumAttrs()
+	found := false
+	i := 0
+	r.Attrs(func(a slog.Attr) bool {
+		_, found = m.bareError(a, i, n)
+		i++
+		return !found
+	})
+	if !found {
+		return r
+	}
+
+	converted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	i = 0
+	r.Attrs(func(a slog.Attr) bool {
+		if err, ok := m.bareError(a, i, n); ok {
+			a = Err(err)
+		}
+		converted.AddAttrs(a)
+		i++
+		return true
+	})
+
+	return converted
+}
+
+// errorKindKey is the key of the attribute with the kind of a logged error.
+const errorKindKey = "error_kind"
+
+// recordErrors returns the fields attached with serrors.With to the errors in the record attributes
+// and the kind of the first error with a kind.
+func recordErrors(r slog.Record) ([]slog.Attr, serrors.Kind) {
+	var (
+		attrs []slog.Attr
+		kind  = serrors.KindUnknown
+	)
+
+	r.Attrs(func(a slog.Attr) bool {
+		if err := attrError(a); err != nil {
+			attrs = append(attrs, serrors.Fields(err)...)
+			if kind == serrors.KindUnknown {
+				kind = serrors.KindOf(err)
+			}
+		}
+		return true
+	})
+
+	return attrs, kind
+}
+
+// attrError returns the error of an attribute with an error or Err value, nil for other attributes.
+// Errors that implement slog.LogValuer, such as the errors of serrors.Collector, have the slog.KindLogValuer kind.
+func attrError(a slog.Attr) error {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		err, _ := a.Value.Any().(error)
+		return err
+	case slog.KindLogValuer:
+		switch v := a.Value.LogValuer().(type) {
+		case errorValue:
+			return v.err
+		case error:
+			return v
+		}
+	default:
+	}
+
+	return nil
+}
+
+// errorValue renders an error as a group, see Err.
+type errorValue struct {
+	err error
+}
+
+// LogValue implements slog.LogValuer.
+func (v errorValue) LogValue() slog.Value {
+	return serrors.LogValue(v.err)
+}
@@ -0,0 +1,411 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/n-r-w/ctxlog/serrors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// notFoundError returns a serrors error created in another function than its caller.
+func notFoundError() error {
+	return serrors.New("not found")
+}
+
+// TestErr verifies the rendering of error attributes.
+func TestErr(t *testing.T) {
+	t.Parallel()
+
+	errWithStack, _ := newStackError()
+	serr := serrors.Error(notFoundError())
+	sites := strings.Fields(serr.Error())[:2]
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: nil,
+		},
+		{
+			name: "plain",
+			err:  errors.New("plain"),
+			want: map[string]any{"msg": "plain", "type": "*errors.errorString"},
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("outer: %w", errors.New("inner")),
+			want: map[string]any{
+				"msg":   "outer: inner",
+				"type":  "*fmt.wrapError",
+				"chain": []any{"*fmt.wrapError", "*errors.errorString"},
+			},
+		},
+		{
+			name: "serrors",
+			err:  serr,
+			want: map[string]any{
+				"msg":   serr.Error(),
+				"type":  "*serrors.callError",
+				"chain": []any{"*serrors.callError", "*serrors.callError"},
+				"sites": []any{strings.Trim(sites[0], "{}"), strings.Trim(sites[1], "{}")},
+			},
+		},
+		{
+			name: "annotated",
+			err:  serrors.With(serrors.WithKind(errors.New("timeout"), serrors.KindTimeout), "key", "value"),
+			want: map[string]any{"msg": "timeout", "type": "*errors.errorString", "kind": "timeout"},
+		},
+		{
+			name: "joined",
+			err:  errors.Join(errors.New("first"), fmt.Errorf("second: %w", errors.New("cause"))),
+			want: map[string]any{
+				"msg":  "first\nsecond: cause",
+				"type": "*errors.joinError",
+				"branches": map[string]any{
+					"0": map[string]any{"msg": "first", "type": "*errors.errorString"},
+					"1": map[string]any{
+						"msg":   "second: cause",
+						"type":  "*fmt.wrapError",
+						"chain": []any{"*fmt.wrapError", "*errors.errorString"},
+					},
+				},
+			},
+		},
+		{
+			name: "stack",
+			err:  errWithStack,
+			want: map[string]any{
+				"msg":   "stack error",
+				"type":  "ctxlog.stackError",
+				"stack": serrors.FormatStack(errWithStack.pcs),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(WithFormat(FormatJSON), WithTesting(t), WithTestBuffer(buffer))
+			require.NoError(t, err)
+
+			logger.Info(context.Background(), "error message", Err(tt.err))
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+
+			if tt.want == nil {
+				require.NotContains(t, record, "error")
+				return
+			}
+			require.Equal(t, tt.want, record["error"])
+		})
+	}
+}
+
+// TestErr_RecordStack verifies that the stack of an Err attribute is used for the record stack trace.
+func TestErr_RecordStack(t *testing.T) {
+	t.Parallel()
+
+	errWithStack, _ := newStackError()
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(WithFormat(FormatJSON), WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	logger.Log(context.Background(), slog.LevelError, "error message", Err(errWithStack))
+	require.NoError(t, logger.Sync())
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+	require.Equal(t, serrors.FormatStack(errWithStack.pcs), record["stacktrace"])
+}
+
+// TestBareErrors verifies the conversion of error arguments without a key.
+func TestBareErrors(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("failed")
+	converted := map[string]any{"msg": "failed", "type": "*errors.errorString"}
+
+	tests := []struct {
+		name   string
+		mode   BareErrorMode
+		with   []any
+		args   []any
+		want   map[string]any
+		wantNo []string // keys that must be absent
+	}{
+		{
+			name:   "standalone",
+			args:   []any{errFailed},
+			want:   map[string]any{"error": converted},
+			wantNo: []string{badKey},
+		},
+		{
+			name:   "leading in any mode",
+			args:   []any{errFailed, "id", 1},
+			want:   map[string]any{"error": converted, "id": float64(1)},
+			wantNo: []string{badKey},
+		},
+		{
+			name:   "trailing in trailing mode",
+			mode:   BareErrorTrailing,
+			args:   []any{"id", 1, errFailed},
+			want:   map[string]any{"error": converted, "id": float64(1)},
+			wantNo: []string{badKey},
+		},
+		{
+			name:   "leading in trailing mode",
+			mode:   BareErrorTrailing,
+			args:   []any{errFailed, "id", 1},
+			want:   map[string]any{badKey: "failed", "id": float64(1)},
+			wantNo: []string{"error"},
+		},
+		{
+			name:   "strict mode",
+			mode:   BareErrorStrict,
+			args:   []any{errFailed},
+			want:   map[string]any{badKey: "failed"},
+			wantNo: []string{"error"},
+		},
+		{
+			name:   "not an error",
+			args:   []any{"value"},
+			want:   map[string]any{badKey: "value"},
+			wantNo: []string{"error"},
+		},
+		{
+			name:   "logger attributes",
+			with:   []any{errFailed},
+			args:   []any{"id", 1},
+			want:   map[string]any{"error": converted, "id": float64(1)},
+			wantNo: []string{badKey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(
+				WithFormat(FormatJSON),
+				WithSource(false),
+				WithBareErrors(tt.mode),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			ctx := ToContext(context.Background(), logger)
+			if tt.with != nil {
+				ctx = With(ctx, tt.with...)
+			}
+			Warn(ctx, "bare error message", tt.args...)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+
+			for k, v := range tt.want {
+				require.Equal(t, v, record[k], k)
+			}
+			for _, k := range tt.wantNo {
+				require.NotContains(t, record, k)
+			}
+		})
+	}
+}
+
+// TestErrorFields verifies that the fields of logged errors are added to the record.
+func TestErrorFields(t *testing.T) {
+	t.Parallel()
+
+	inner := serrors.With(errors.New("not found"), "order_id", 10, "attempt", 1)
+	err := serrors.With(fmt.Errorf("load: %w", inner), "attempt", 2)
+
+	tests := []struct {
+		name string
+		args []any
+	}{
+		{name: "error value", args: []any{"error", err}},
+		{name: "Err attribute", args: []any{Err(err)}},
+		{name: "bare error", args: []any{err}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, lErr := New(WithFormat(FormatJSON), WithTesting(t), WithTestBuffer(buffer))
+			require.NoError(t, lErr)
+
+			logger.Info(context.Background(), "fields message", tt.args...)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.InDelta(t, 10, record["order_id"], 0)
+			require.InDelta(t, 2, record["attempt"], 0)
+		})
+	}
+}
+
+// TestErrorKind verifies the error kind attribute and the error kind levels.
+func TestErrorKind(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := serrors.NotFound("order not found")
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		err       error
+		wantLevel string // empty if the record is dropped
+		wantKind  any
+	}{
+		{
+			name:      "kind attribute",
+			err:       errNotFound,
+			wantLevel: "ERROR",
+			wantKind:  "not_found",
+		},
+		{
+			name:      "no kind",
+			err:       errors.New("failed"),
+			wantLevel: "ERROR",
+			wantKind:  nil,
+		},
+		{
+			name:      "kind level",
+			opts:      []Option{WithErrorKindLevel(serrors.KindNotFound, slog.LevelInfo)},
+			err:       fmt.Errorf("load: %w", errNotFound),
+			wantLevel: "INFO",
+			wantKind:  "not_found",
+		},
+		{
+			name: "kind level below the logger level",
+			opts: []Option{
+				WithErrorKindLevel(serrors.KindNotFound, slog.LevelInfo),
+				WithLevel(slog.LevelWarn),
+			},
+			err: errNotFound,
+		},
+		{
+			name:      "other kind level",
+			opts:      []Option{WithErrorKindLevel(serrors.KindConflict, slog.LevelInfo)},
+			err:       errNotFound,
+			wantLevel: "ERROR",
+			wantKind:  "not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(append(tt.opts,
+				WithFormat(FormatJSON),
+				WithStacktraceLevel(nil),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)...)
+			require.NoError(t, err)
+
+			logger.Error(context.Background(), "kind message", Err(tt.err))
+			require.NoError(t, logger.Sync())
+
+			if tt.wantLevel == "" {
+				require.Empty(t, buffer.String())
+				return
+			}
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.Equal(t, tt.wantLevel, record["level"])
+			require.Equal(t, tt.wantKind, record["error_kind"])
+		})
+	}
+}
+
+// TestErr_Collector verifies the logging of the errors of serrors.Collector.
+func TestErr_Collector(t *testing.T) {
+	t.Parallel()
+
+	var c serrors.Collector
+	c.AddFieldf("items[2].price", "must be positive")
+	c.Add(serrors.With(serrors.NotFound("missing"), "order_id", 10))
+	err := c.Err()
+
+	// all ways to log the error render the same structure
+	tests := []struct {
+		name string
+		args []any
+	}{
+		{
+			name: "bare error",
+			args: []any{err},
+		},
+		{
+			name: "error value",
+			args: []any{"error", err},
+		},
+		{
+			name: "Err",
+			args: []any{Err(err)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, lErr := New(WithFormat(FormatJSON), WithTesting(t), WithTestBuffer(buffer))
+			require.NoError(t, lErr)
+
+			logger.Warn(context.Background(), "collector message", tt.args...)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.NotContains(t, record, badKey)
+			require.InDelta(t, 10, record["order_id"], 0)
+			require.Equal(t, "not_found", record[errorKindKey])
+
+			value, ok := record["error"].(map[string]any)
+			require.True(t, ok)
+			require.Equal(t, err.Error(), value["msg"])
+			branches, ok := value["branches"].(map[string]any)
+			require.True(t, ok)
+			first, ok := branches["0"].(map[string]any)
+			require.True(t, ok)
+			require.Equal(t, "items[2].price", first["field"])
+			second, ok := branches["1"].(map[string]any)
+			require.True(t, ok)
+			require.NotContains(t, second, "field")
+			require.Equal(t, "not_found", second["kind"])
+		})
+	}
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 
 	"github.com/n-r-w/ctxlog"
@@ -34,6 +35,9 @@ func main() {
 	// Logging errors with automatic stack trace
 	ctxlog.Error(ctx, "failed to connect to database", "host", "localhost", "port", 5432)
 
+	// Logging the structure of an error: message, type, unwrapped chain and joined branches
+	ctxlog.Warn(ctx, "retrying request", ctxlog.Err(fmt.Errorf("request failed: %w", errors.New("timeout"))))
+
 	// CloseError close io.Closer and log any error
 	ctxlog.CloseError(ctx, &SomeCloser{})
 
@@ -0,0 +1,102 @@
+package ctxlog
+
+import (
+	"flag"
+	"log/slog"
+)
+
+// Flags holds logger settings registered on a flag.FlagSet by RegisterFlags.
+type Flags struct {
+	fs *flag.FlagSet
+
+	level      levelValue
+	env        EnvType
+	format     Format
+	color      ColorMode
+	source     bool
+	name       string
+	timeLayout string
+	output     string
+}
+
+// Flag names registered by RegisterFlags.
+const (
+	flagLevel      = "log-level"
+	flagEnv        = "log-env"
+	flagFormat     = "log-format"
+	flagColor      = "log-color"
+	flagSource     = "log-source"
+	flagName       = "log-name"
+	flagTimeLayout = "log-time-layout"
+	flagOutput     = "log-output"
+)
+
+// RegisterFlags defines logger flags on the flag set:
+// -log-level, -log-env, -log-format, -log-color, -log-source, -log-name, -log-time-layout and -log-output.
+// After parsing, call Flags.Options to get the logger options.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{ //nolint:exhaustruct // defaults are set below
+		fs:     fs,
+		level:  levelValue(slog.LevelDebug),
+		env:    EnvProduction,
+		source: true,
+	}
+
+	fs.Var(&f.level, flagLevel, "minimum logging level: DEBUG, INFO, WARN, ERROR")
+	fs.Var(&f.env, flagEnv, "logger environment mode: DEV, DEVELOPMENT, PROD, PRODUCTION")
+	fs.Var(&f.format, flagFormat, "log output format: AUTO, CONSOLE, JSON, LOGFMT, PRETTY")
+	fs.Var(&f.color, flagColor, "colored output: AUTO, ALWAYS, NEVER")
+	fs.BoolVar(&f.source, flagSource, f.source, "add the file name and line number of the call to log records")
+	fs.StringVar(&f.name, flagName, "", "logger name")
+	fs.StringVar(&f.timeLayout, flagTimeLayout, "", "time layout of log records (default RFC3339Nano)")
+	fs.StringVar(&f.output, flagOutput, "", "comma-separated output paths (default stderr)")
+
+	return f
+}
+
+// Options returns the logger options for the flags that were set on the command line.
+// Flags that were not set keep the defaults of New.
+func (f *Flags) Options() []Option {
+	var opts []Option
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case flagLevel:
+			opts = append(opts, WithLevel(slog.Level(f.level)))
+		case flagEnv:
+			opts = append(opts, WithEnvType(f.env))
+		case flagFormat:
+			opts = append(opts, WithFormat(f.format))
+		case flagColor:
+			opts = append(opts, WithColor(f.color))
+		case flagSource:
+			opts = append(opts, WithSource(f.source))
+		case flagName:
+			opts = append(opts, WithName(f.name))
+		case flagTimeLayout:
+			opts = append(opts, WithTimeLayout(f.timeLayout))
+		case flagOutput:
+			opts = append(opts, WithOutputPaths(splitList(f.output)...))
+		}
+	})
+
+	return opts
+}
+
+// levelValue implements flag.Value for slog.Level using ParseLogLevel.
+type levelValue slog.Level
+
+// String implements flag.Value.
+func (v *levelValue) String() string {
+	return slog.Level(*v).String()
+}
+
+// Set implements flag.Value.
+func (v *levelValue) Set(s string) error {
+	level, err := ParseLogLevel(s)
+	if err != nil {
+		return err
+	}
+	*v = levelValue(level)
+	return nil
+}
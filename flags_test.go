@@ -0,0 +1,76 @@
+package ctxlog
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegisterFlags verifies that parsed flags are converted into options.
+func TestRegisterFlags(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+
+	require.NoError(t, fs.Parse([]string{
+		"-log-level", "warning",
+		"-log-env", "dev",
+		"-log-format", "json",
+		"-log-source=false",
+		"-log-name", "cli",
+		"-log-output", "stdout,/tmp/cli.log",
+	}))
+
+	var o options
+	for _, opt := range flags.Options() {
+		opt(&o)
+	}
+
+	require.Equal(t, slog.LevelWarn, o.level)
+	require.Equal(t, EnvDevelopment, o.env)
+	require.Equal(t, FormatJSON, o.format)
+	require.False(t, o.addSource)
+	require.Equal(t, "cli", o.name)
+	require.Equal(t, []string{"stdout", "/tmp/cli.log"}, o.outputPaths)
+}
+
+// TestRegisterFlags_Defaults verifies that flags not set on the command line produce no options.
+func TestRegisterFlags_Defaults(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+
+	require.NoError(t, fs.Parse([]string{"-log-level", "error"}))
+	require.Len(t, flags.Options(), 1)
+}
+
+// TestRegisterFlags_Invalid verifies that invalid values are rejected by the flag set.
+func TestRegisterFlags_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "level", args: []string{"-log-level", "verbose"}},
+		{name: "env", args: []string{"-log-env", "staging"}},
+		{name: "format", args: []string{"-log-format", "xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			RegisterFlags(fs)
+
+			require.Error(t, fs.Parse(tt.args))
+		})
+	}
+}
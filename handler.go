@@ -4,96 +4,122 @@ import (
 	"context"
 	"log/slog"
 	"runtime"
-	"strings"
 
+	"github.com/n-r-w/ctxlog/serrors"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
 )
 
 type handler struct {
 	slog.Handler
 
-	level     slog.Leveler
-	logSource bool
+	conf *handlerConfig
+}
+
+// handlerConfig is shared by a handler and all handlers derived from it.
+type handlerConfig struct {
+	level      slog.Leveler
+	logSource  bool
+	source     sourceFormat
+	sources    sourceCache
+	state      *loggerState
+	bareErrors BareErrorMode
+	// kindLevels are the levels of records with errors of these kinds.
+	kindLevels map[serrors.Kind]slog.Level
+	// stacktraceLevel is the minimum level of records with a stack trace, nil if disabled.
+	stacktraceLevel slog.Leveler
+	// traceFields returns the trace fields of the format preset, nil if no preset is set.
+	traceFields func(trace.SpanContext) []zapcore.Field
+	// otel adds records as span events, nil if disabled.
+	otel *otelzap.Logger
 }
 
 var _ slog.Handler = (*handler)(nil)
 
-func newHandler(h slog.Handler, level slog.Leveler, logSource bool) slog.Handler {
+func newHandler(h slog.Handler, conf *handlerConfig) slog.Handler {
 	return handler{
-		Handler:   h,
-		level:     level,
-		logSource: logSource,
+		Handler: h,
+		conf:    conf,
 	}
 }
 
 type callStackSkipKey struct{}
 
-// ctxCallStackSkipKey is a context key that skips the call stack.
+// ctxCallStackSkipKey is a context key that overrides the stack frame skip of LogWithLevel.
 var ctxCallStackSkipKey = callStackSkipKey{} //nolint:gochecknoglobals // ok for context
 
 // Handle adds attributes from context to the record and then calls the handler.
 func (h handler) Handle(ctx context.Context, r slog.Record) error {
-	if h.logSource && r.PC != 0 {
-		const (
-			maxCallers  = 100
-			defaultSkip = 4
+	r = h.conf.bareErrors.convertRecord(r)
+
+	fields, kind := recordErrors(r)
+	if kind != serrors.KindUnknown {
+		if level, ok := h.conf.kindLevels[kind]; ok {
+			if level < h.conf.level.Level() {
+				return nil
+			}
+			r.Level = level
+		}
+		fields = append(fields, slog.String(errorKindKey, kind.String()))
+	}
+	if len(fields) > 0 {
+		r.AddAttrs(fields...)
+	}
+
+	// the caller of a helper, set if it is inlined and cannot be found by r.PC
+	var inlinedCaller *runtime.Frame
+	if r.PC != 0 && _helpers.isHelper(r.PC) {
+		var (
+			frame   runtime.Frame
+			inlined bool
 		)
+		r.PC, frame, inlined = _helpers.caller(r.PC)
+		if inlined {
+			inlinedCaller = &frame
+		}
+	}
 
-		skip, ok := ctx.Value(ctxCallStackSkipKey).(int)
-		if !ok {
-			skip = defaultSkip
+	if h.conf.logSource && r.PC != 0 {
+		if inlinedCaller != nil {
+			r.AddAttrs(h.conf.source.attr(*inlinedCaller))
+		} else {
+			r.AddAttrs(h.conf.sources.attr(r.PC, h.conf.source))
 		}
+	}
 
-		pc := make([]uintptr, maxCallers)
-		nCallers := runtime.Callers(skip, pc)
-		frame, _ := runtime.CallersFrames(pc[:nCallers]).Next()
+	if h.conf.otel != nil {
+		otelEvent(ctx, h.conf.otel, r)
+	}
+
+	if h.conf.stacktraceLevel != nil && r.Level >= h.conf.stacktraceLevel.Level() {
+		r.AddAttrs(slog.Any("", entryStack(recordStack(r))))
+	}
 
-		r.AddAttrs(slog.String("source", trimmedPath(frame.File, frame.Line)))
+	if h.conf.traceFields != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.Any("", topLevelFields(h.conf.traceFields(sc))))
+		}
 	}
 
 	return h.Handler.Handle(ctx, r)
 }
 
-// taken from zapcore.EntryCaller.TrimmedPath.
+// _pool is a pool of buffers used by the encoders.
 var _pool = buffer.NewPool() //nolint:gochecknoglobals // singleton
 
-func trimmedPath(file string, line int) string {
-	idx := strings.LastIndexByte(file, '/')
-	if idx == -1 {
-		return file
-	}
-	// Find the penultimate separator.
-	idx = strings.LastIndexByte(file[:idx], '/')
-	if idx == -1 {
-		return file
-	}
-	buf := _pool.Get()
-	// Keep everything after the penultimate separator.
-	buf.AppendString(file[idx+1:])
-	buf.AppendByte(':')
-	buf.AppendInt(int64(line))
-	caller := buf.String()
-	buf.Free()
-	return caller
-}
-
 // Enabled reports whether records at the specified level should be processed.
 func (h handler) Enabled(_ context.Context, level slog.Level) bool {
-	return h.level.Level() <= level
+	return !h.conf.state.closed.Load() && h.conf.level.Level() <= level
 }
 
 // WithAttrs returns a new handler that has attributes from both handlers.
 func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return newHandler(
-		h.Handler.WithAttrs(attrs),
-		h.level,
-		h.logSource)
+	return newHandler(h.Handler.WithAttrs(h.conf.bareErrors.convertAttrs(attrs)), h.conf)
 }
 
 // WithGroup returns a new handler with a group added to the handler.
 func (h handler) WithGroup(group string) slog.Handler {
-	return newHandler(
-		h.Handler.WithGroup(group),
-		h.level,
-		h.logSource)
+	return newHandler(h.Handler.WithGroup(group), h.conf)
 }
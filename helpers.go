@@ -47,6 +47,7 @@ func WithGroup(ctx context.Context, name string) context.Context {
 }
 
 // SetSkipCallStack sets the number of stack frames to skip when logging.
+// Prefer Helper, which doesn't depend on the depth of the call stack.
 func SetSkipCallStack(ctx context.Context, skip int) context.Context {
 	return context.WithValue(ctx, ctxCallStackSkipKey, skip)
 }
@@ -68,12 +69,17 @@ func Sync(ctx context.Context) error {
 	return FromContext(ctx).Sync()
 }
 
+// Close closes the logger in the context. See Logger.Close.
+func Close(ctx context.Context) error {
+	return FromContext(ctx).Close(ctx)
+}
+
 // CloseError closes c, logging any error that occurs.
 func CloseError(ctx context.Context, c io.Closer) {
 	log := FromContext(ctx)
 	t := reflect.TypeOf(c).String()
 	if err := c.Close(); err != nil {
-		log.Error(ctx, "failed to close", slog.String("type", t), slog.Any("error", err))
+		log.Error(ctx, "failed to close", slog.String("type", t), Err(err))
 	}
 }
 
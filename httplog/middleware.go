@@ -0,0 +1,156 @@
+// Package httplog provides net/http middleware and an http.RoundTripper that log requests with ctxlog.
+package httplog
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/n-r-w/ctxlog"
+	"github.com/n-r-w/ctxlog/serrors"
+)
+
+// Middleware returns a middleware that adds a logger for each request to the request context.
+// The logger is derived from the base logger and has the attributes:
+//   - request_id: the valid request ID from the request ID header or a generated one,
+//     also set in the response header, see WithRequestIDHeader.
+//   - method: the request method.
+//   - remote_addr: the remote address of the request.
+//   - route: the pattern of the http.ServeMux route of the request, if the mux is set by WithRoutes.
+//
+// The middleware recovers panics of the handler: it logs the panic at slog.LevelError with the stack
+// of the panic and responds with 500 if the response was not started. If the response was started,
+// it panics with http.ErrAbortHandler after logging, so the server aborts the response.
+// http.ErrAbortHandler is not recovered.
+//
+// After the handler returns, it logs the "http request" access record with the attributes:
+//   - route: the pattern of the http.ServeMux route of the request, if it has one.
+//   - status: the response status, 101 if the connection was hijacked.
+//   - bytes: the number of bytes written to the response body.
+//   - duration: the duration of the request.
+//
+// The level of the access record is derived from the status, see WithStatusLevel.
+func Middleware(base *ctxlog.Logger, opts ...Option) func(http.Handler) http.Handler {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(o.requestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = o.newRequestID()
+			}
+			w.Header().Set(o.requestIDHeader, requestID)
+
+			attrs := make([]any, 0, 4) //nolint:mnd // the number of logger attributes
+			attrs = append(attrs,
+				slog.String("request_id", requestID),
+				slog.String("method", r.Method),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+			if o.mux != nil {
+				if _, route := o.mux.Handler(r); route != "" {
+					attrs = append(attrs, slog.String("route", route))
+				}
+			}
+			logger := base.With(attrs...)
+			ctx := ctxlog.ToContext(r.Context(), logger)
+			r = r.WithContext(ctx)
+			rw := &responseWriter{ResponseWriter: w, status: 0, bytes: 0}
+
+			defer func() {
+				// the response of a panicking handler is aborted if it was started
+				abort := false
+				if v := recover(); v != nil {
+					if v == http.ErrAbortHandler { //nolint:errorlint,err113 // the sentinel value of net/http
+						panic(v)
+					}
+
+					logger.Error(ctx, "http handler panic", ctxlog.Err(serrors.FromPanic(v)))
+					if rw.status == 0 {
+						rw.WriteHeader(http.StatusInternalServerError)
+					} else {
+						abort = true
+					}
+				}
+
+				status := rw.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				attrs := make([]any, 0, 4) //nolint:mnd // the number of access log attributes
+				if r.Pattern != "" && o.mux == nil {
+					attrs = append(attrs, slog.String("route", r.Pattern))
+				}
+				attrs = append(attrs,
+					slog.Int("status", status),
+					slog.Int64("bytes", rw.bytes),
+					slog.Duration("duration", time.Since(start)),
+				)
+				ctxlog.Log(ctx, o.statusLevel(status), "http request", attrs...)
+
+				if abort {
+					panic(http.ErrAbortHandler)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// responseWriter records the status and the number of written bytes of the response.
+type responseWriter struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int64
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	// informational statuses are followed by the final one
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err //nolint:wrapcheck // the error of the wrapped writer
+}
+
+// Flush implements http.Flusher.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err //nolint:wrapcheck // the error of the wrapped writer
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,331 @@
+package httplog
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/n-r-w/ctxlog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestLogger(t *testing.T, opts ...ctxlog.Option) (*ctxlog.Logger, *zaptest.Buffer) {
+	t.Helper()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+	logger, err := ctxlog.New(append([]ctxlog.Option{
+		ctxlog.WithFormat(ctxlog.FormatJSON), ctxlog.WithTesting(t), ctxlog.WithTestBuffer(buffer),
+	}, opts...)...)
+	require.NoError(t, err)
+
+	return logger, buffer
+}
+
+func records(t *testing.T, logger *ctxlog.Logger, buffer *zaptest.Buffer) []map[string]any {
+	t.Helper()
+	require.NoError(t, logger.Sync())
+
+	var result []map[string]any
+	for _, line := range buffer.Lines() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		requestID string
+		handler   http.HandlerFunc
+		status    int
+		level     string
+		body      string
+	}{
+		{
+			name:      "ok",
+			requestID: "req-1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				ctxlog.Info(r.Context(), "handler message")
+				_, _ = io.WriteString(w, "hello")
+			},
+			status: http.StatusOK,
+			level:  "INFO",
+			body:   "hello",
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				ctxlog.Info(r.Context(), "handler message")
+				http.Error(w, "missing", http.StatusNotFound)
+			},
+			status: http.StatusNotFound,
+			level:  "WARN",
+			body:   "missing\n",
+		},
+		{
+			name: "panic",
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				ctxlog.Info(r.Context(), "handler message")
+				panic("boom")
+			},
+			status: http.StatusInternalServerError,
+			level:  "ERROR",
+			body:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, buffer := newTestLogger(t)
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /orders/{id}", tt.handler)
+			handler := Middleware(logger, WithRequestIDGenerator(func() string { return "generated" }))(mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/orders/10", nil)
+			if tt.requestID != "" {
+				req.Header.Set(DefaultRequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			wantID := tt.requestID
+			if wantID == "" {
+				wantID = "generated"
+			}
+			require.Equal(t, tt.status, rec.Code)
+			require.Equal(t, tt.body, rec.Body.String())
+			require.Equal(t, wantID, rec.Header().Get(DefaultRequestIDHeader))
+
+			logged := records(t, logger, buffer)
+			require.Equal(t, "handler message", logged[0]["msg"])
+			for _, record := range logged {
+				require.Equal(t, wantID, record["request_id"])
+				require.Equal(t, http.MethodGet, record["method"])
+				require.Equal(t, req.RemoteAddr, record["remote_addr"])
+			}
+
+			if tt.name == "panic" {
+				require.Len(t, logged, 3)
+				require.Equal(t, "http handler panic", logged[1]["msg"])
+				require.Contains(t, logged[1]["stacktrace"], "httplog.TestMiddleware")
+			} else {
+				require.Len(t, logged, 2)
+			}
+
+			access := logged[len(logged)-1]
+			require.Equal(t, "http request", access["msg"])
+			require.Equal(t, tt.level, access["level"])
+			require.Equal(t, "GET /orders/{id}", access["route"])
+			require.InDelta(t, tt.status, access["status"], 0)
+			require.InDelta(t, len(tt.body), access["bytes"], 0)
+			require.Contains(t, access, "duration")
+		})
+	}
+}
+
+func TestMiddleware_AbortHandler(t *testing.T) {
+	t.Parallel()
+
+	logger, _ := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestMiddleware_PanicAfterResponseStarted(t *testing.T) {
+	t.Parallel()
+
+	logger, buffer := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "partial")
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "partial", rec.Body.String())
+
+	logged := records(t, logger, buffer)
+	require.Len(t, logged, 2)
+	require.Equal(t, "http handler panic", logged[0]["msg"])
+	require.Equal(t, "http request", logged[1]["msg"])
+	require.InDelta(t, http.StatusOK, logged[1]["status"], 0)
+	require.InDelta(t, len("partial"), logged[1]["bytes"], 0)
+}
+
+func TestMiddleware_PanicStrictBareErrors(t *testing.T) {
+	t.Parallel()
+
+	logger, buffer := newTestLogger(t, ctxlog.WithBareErrors(ctxlog.BareErrorStrict))
+	handler := Middleware(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	logged := records(t, logger, buffer)
+	require.Len(t, logged, 2)
+	require.Equal(t, "http handler panic", logged[0]["msg"])
+	require.Contains(t, logged[0], "error")
+	require.NotContains(t, logged[0], "!BADKEY")
+	require.NotContains(t, logged[0], "!BADKEYVerbose")
+}
+
+func TestMiddleware_Hijack(t *testing.T) {
+	t.Parallel()
+
+	logger, buffer := newTestLogger(t)
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = buf.Flush()
+	}))
+
+	// the access record is logged after the client gets the response
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL) //nolint:noctx // test request
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "hijacked", string(body))
+	<-done
+
+	logged := records(t, logger, buffer)
+	require.Len(t, logged, 1)
+	require.InDelta(t, http.StatusSwitchingProtocols, logged[0]["status"], 0)
+
+	// the recorder doesn't support hijacking
+	var hijackErr error
+	Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _, hijackErr = http.NewResponseController(w).Hijack()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.ErrorIs(t, hijackErr, http.ErrNotSupported)
+}
+
+func TestMiddleware_Routes(t *testing.T) {
+	t.Parallel()
+
+	logger, buffer := newTestLogger(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(_ http.ResponseWriter, r *http.Request) {
+		ctxlog.Info(r.Context(), "handler message")
+	})
+	handler := Middleware(logger, WithRoutes(mux))(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/10", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	logged := records(t, logger, buffer)
+	require.Len(t, logged, 3)
+	require.Equal(t, "handler message", logged[0]["msg"])
+	require.Equal(t, "GET /orders/{id}", logged[0]["route"])
+	require.Equal(t, "GET /orders/{id}", logged[1]["route"])
+	require.InDelta(t, http.StatusNotFound, logged[2]["status"], 0)
+	require.NotContains(t, logged[2], "route")
+}
+
+func TestMiddleware_RequestIDValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		requestID string
+		want      string
+	}{
+		{name: "valid", requestID: "req-1:a/b", want: "req-1:a/b"},
+		{name: "max length", requestID: strings.Repeat("a", 128), want: strings.Repeat("a", 128)},
+		{name: "too long", requestID: strings.Repeat("a", 129), want: "generated"},
+		{name: "space", requestID: "req 1", want: "generated"},
+		{name: "control character", requestID: "req\x1b[31m", want: "generated"},
+		{name: "non-ASCII", requestID: "запрос", want: "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, buffer := newTestLogger(t)
+			handler := Middleware(logger, WithRequestIDGenerator(func() string { return "generated" }))(
+				http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(DefaultRequestIDHeader, tt.requestID)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want, rec.Header().Get(DefaultRequestIDHeader))
+			logged := records(t, logger, buffer)
+			require.Len(t, logged, 1)
+			require.Equal(t, tt.want, logged[0]["request_id"])
+		})
+	}
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	t.Parallel()
+
+	logger, buffer := newTestLogger(t)
+	handler := Middleware(logger,
+		WithRequestIDHeader("X-Trace"),
+		WithStatusLevel(func(int) slog.Level { return slog.LevelDebug }),
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		http.NewResponseController(w).Flush() //nolint:errcheck // the recorder supports flushing
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader("{}")))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.True(t, rec.Flushed)
+	require.Len(t, rec.Header().Get("X-Trace"), 32)
+
+	logged := records(t, logger, buffer)
+	require.Len(t, logged, 1)
+	require.Equal(t, "DEBUG", logged[0]["level"])
+	require.NotContains(t, logged[0], "route")
+	require.InDelta(t, http.StatusAccepted, logged[0]["status"], 0)
+}
+
+func TestStatusLevel(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, slog.LevelInfo, StatusLevel(http.StatusOK))
+	require.Equal(t, slog.LevelInfo, StatusLevel(http.StatusFound))
+	require.Equal(t, slog.LevelWarn, StatusLevel(http.StatusBadRequest))
+	require.Equal(t, slog.LevelError, StatusLevel(http.StatusBadGateway))
+}
@@ -0,0 +1,105 @@
+package httplog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the default header of the request ID.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of a request ID taken from the request header.
+const maxRequestIDLength = 128
+
+// Option is a function for configuring the middleware.
+type Option func(*options)
+
+type options struct {
+	requestIDHeader string
+	newRequestID    func() string
+	statusLevel     func(status int) slog.Level
+	mux             *http.ServeMux
+}
+
+func defaultOptions() options {
+	return options{
+		requestIDHeader: DefaultRequestIDHeader,
+		newRequestID:    newRequestID,
+		statusLevel:     StatusLevel,
+		mux:             nil,
+	}
+}
+
+// WithRequestIDHeader sets the header that contains the request ID of incoming requests.
+// The middleware sets the header in the response. A request ID longer than 128 characters or with characters
+// other than visible ASCII ones is replaced with a generated one.
+// default: DefaultRequestIDHeader.
+func WithRequestIDHeader(header string) Option {
+	return func(o *options) {
+		o.requestIDHeader = header
+	}
+}
+
+// WithRequestIDGenerator sets the function that generates the request ID of requests without the header.
+// default: 16 random bytes in hex.
+func WithRequestIDGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.newRequestID = fn
+	}
+}
+
+// WithStatusLevel sets the function that returns the level of the access log record for the response status.
+// default: StatusLevel.
+func WithStatusLevel(fn func(status int) slog.Level) Option {
+	return func(o *options) {
+		o.statusLevel = fn
+	}
+}
+
+// WithRoutes sets the mux that serves the requests. The middleware resolves the route of the request
+// with mux.Handler before calling the handler, so the request logger has the "route" attribute.
+// Without it, the route is known only after the handler returns and is added only to the access record.
+// default: nil.
+func WithRoutes(mux *http.ServeMux) Option {
+	return func(o *options) {
+		o.mux = mux
+	}
+}
+
+// StatusLevel returns slog.LevelError for 5xx statuses, slog.LevelWarn for 4xx statuses
+// and slog.LevelInfo for others.
+func StatusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// validRequestID reports whether the request ID from the request header can be used:
+// it has at most maxRequestIDLength visible ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := range len(id) {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns 16 random bytes in hex.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,333 @@
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/n-r-w/ctxlog"
+)
+
+// redacted replaces the values of redacted query parameters and headers.
+const redacted = "REDACTED"
+
+// DefaultRedactedQuery are the query parameters redacted by default.
+var DefaultRedactedQuery = []string{ //nolint:gochecknoglobals // default settings
+	"access_token", "api_key", "apikey", "key", "password", "secret", "signature", "token",
+}
+
+// DefaultRedactedHeaders are the headers redacted by default.
+var DefaultRedactedHeaders = []string{ //nolint:gochecknoglobals // default settings
+	"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie", "X-Api-Key",
+}
+
+// TransportOption is a function for configuring the transport.
+type TransportOption func(*transportOptions)
+
+type transportOptions struct {
+	redactedQuery   map[string]struct{}
+	redactedHeaders map[string]struct{}
+	bodyLimit       int
+	statusLevel     func(status int) slog.Level
+}
+
+// WithRedactedQuery sets the query parameters whose values are redacted in the logged URL.
+// The names are case-insensitive.
+// default: DefaultRedactedQuery.
+func WithRedactedQuery(names ...string) TransportOption {
+	return func(o *transportOptions) {
+		o.redactedQuery = nameSet(names, strings.ToLower)
+	}
+}
+
+// WithRedactedHeaders sets the headers whose values are redacted in the logged headers.
+// default: DefaultRedactedHeaders.
+func WithRedactedHeaders(names ...string) TransportOption {
+	return func(o *transportOptions) {
+		o.redactedHeaders = nameSet(names, http.CanonicalHeaderKey)
+	}
+}
+
+// WithBodies enables logging of the headers and the bodies of requests and responses at slog.LevelDebug,
+// the bodies are truncated to limit bytes. 0 disables logging of bodies.
+// default: 0.
+func WithBodies(limit int) TransportOption {
+	return func(o *transportOptions) {
+		o.bodyLimit = limit
+	}
+}
+
+// WithTransportStatusLevel sets the function that returns the level of the record for the response status.
+// default: StatusLevel.
+func WithTransportStatusLevel(fn func(status int) slog.Level) TransportOption {
+	return func(o *transportOptions) {
+		o.statusLevel = fn
+	}
+}
+
+// nameSet returns the set of the normalized names.
+func nameSet(names []string, normalize func(string) string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[normalize(name)] = struct{}{}
+	}
+	return set
+}
+
+type attemptsKey struct{}
+
+// TrackAttempts returns a context that counts the requests sent with it by the transport.
+// Use it for all attempts of a request with retries: the records of the transport get the "attempt" attribute.
+func TrackAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, new(atomic.Int32))
+}
+
+// Transport is an http.RoundTripper that logs outgoing requests with the logger of the request context.
+// Requests with contexts without a logger are not logged.
+//
+// After the response is received, it logs the "http client request" record with the attributes:
+//   - method: the request method.
+//   - url: the request URL with redacted query parameters and password.
+//   - attempt: the number of the attempt, if the context is created by TrackAttempts.
+//   - status: the response status, if the response is received.
+//   - duration: the duration of the request until the response headers are received.
+//   - error: the error of the request, if it failed.
+//
+// The level of the record is derived from the status, see WithTransportStatusLevel, and is slog.LevelError
+// for failed requests. If bodies are enabled by WithBodies, the "http client body" record with the redacted
+// headers and the truncated bodies is logged at slog.LevelDebug when the response body is closed.
+// For failed requests, it is logged after the request record, without the response headers and body.
+// For 101 Switching Protocols responses, it is logged right away without the response body,
+// and the body of the response is not wrapped to keep the upgraded connection writable.
+type Transport struct {
+	next http.RoundTripper
+	opts transportOptions
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport returns a Transport that sends requests with next, http.DefaultTransport if next is nil.
+func NewTransport(next http.RoundTripper, opts ...TransportOption) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	o := transportOptions{
+		redactedQuery:   nameSet(DefaultRedactedQuery, strings.ToLower),
+		redactedHeaders: nameSet(DefaultRedactedHeaders, http.CanonicalHeaderKey),
+		bodyLimit:       0,
+		statusLevel:     StatusLevel,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Transport{next: next, opts: o}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger, ok := ctxlog.TryFromContext(ctx)
+	if !ok {
+		return t.next.RoundTrip(req) //nolint:wrapcheck // the error of the wrapped transport
+	}
+
+	attrs := make([]any, 0, 6) //nolint:mnd // the number of record attributes
+	attrs = append(attrs,
+		slog.String("method", req.Method),
+		slog.String("url", t.redactURL(req.URL)),
+	)
+	if attempts, ok := ctx.Value(attemptsKey{}).(*atomic.Int32); ok {
+		attrs = append(attrs, slog.Int("attempt", int(attempts.Add(1))))
+	}
+
+	var body *bodyLog
+	if t.opts.bodyLimit > 0 && logger.Handler().Enabled(ctx, slog.LevelDebug) {
+		body = &bodyLog{
+			ctx:            ctx,
+			logger:         logger,
+			attrs:          slices.Clip(attrs),
+			requestHeaders: t.redactHeaders(req.Header),
+			request:        newCapture(t.opts.bodyLimit),
+			response:       newCapture(t.opts.bodyLimit),
+			once:           sync.Once{},
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			req = req.Clone(ctx)
+			req.Body = &captureReader{ReadCloser: req.Body, capture: body.request, onClose: nil}
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start)
+
+	if err != nil {
+		ctxlog.Log(ctx, slog.LevelError, "http client request", append(attrs,
+			slog.Duration("duration", duration),
+			ctxlog.Err(err),
+		)...)
+		if body != nil {
+			body.log()
+		}
+		return nil, err //nolint:wrapcheck // the error of the wrapped transport
+	}
+
+	ctxlog.Log(ctx, t.opts.statusLevel(resp.StatusCode), "http client request", append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("duration", duration),
+	)...)
+
+	if body != nil {
+		body.responseHeaders = t.redactHeaders(resp.Header)
+		body.attrs = append(body.attrs, slog.Int("status", resp.StatusCode))
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			// the body is the io.ReadWriteCloser of the upgraded connection and is not captured
+			body.log()
+		} else {
+			resp.Body = &captureReader{ReadCloser: resp.Body, capture: body.response, onClose: body.log}
+		}
+	}
+
+	return resp, nil
+}
+
+// redactURL returns the URL with the redacted password and query parameters.
+// Only the values of the redacted parameters are replaced, the order and the encoding of the query are kept.
+func (t *Transport) redactURL(u *url.URL) string {
+	if u.RawQuery == "" || len(t.opts.redactedQuery) == 0 {
+		return u.Redacted()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	changed := false
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if _, ok := t.opts.redactedQuery[strings.ToLower(name)]; ok {
+			params[i] = key + "=" + redacted
+			changed = true
+		}
+	}
+	if !changed {
+		return u.Redacted()
+	}
+
+	c := *u
+	c.RawQuery = strings.Join(params, "&")
+	return c.Redacted()
+}
+
+// redactHeaders returns the headers as attributes with the redacted values.
+func (t *Transport) redactHeaders(h http.Header) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if _, ok := t.opts.redactedHeaders[http.CanonicalHeaderKey(name)]; ok {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int { return strings.Compare(a.Key, b.Key) })
+	return attrs
+}
+
+// bodyLog logs the headers and the bodies of a request and its response.
+type bodyLog struct {
+	ctx             context.Context //nolint:containedctx // the context of the request
+	logger          *ctxlog.Logger
+	attrs           []any
+	requestHeaders  []slog.Attr
+	responseHeaders []slog.Attr // nil if no response was received
+	request         *capture
+	response        *capture
+	once            sync.Once
+}
+
+// log logs the record once, when the response body is closed or the request failed.
+// The response headers and body are logged only if the response was received.
+func (b *bodyLog) log() {
+	b.once.Do(func() {
+		attrs := append(b.attrs,
+			slog.Attr{Key: "request_headers", Value: slog.GroupValue(b.requestHeaders...)},
+			slog.String("request_body", b.request.String()),
+		)
+		if b.responseHeaders != nil {
+			attrs = append(attrs,
+				slog.Attr{Key: "response_headers", Value: slog.GroupValue(b.responseHeaders...)},
+				slog.String("response_body", b.response.String()),
+			)
+		}
+		b.logger.Debug(b.ctx, "http client body", attrs...)
+	})
+}
+
+// capture keeps the first bytes of a body.
+type capture struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func newCapture(limit int) *capture {
+	return &capture{mu: sync.Mutex{}, buf: bytes.Buffer{}, limit: limit, truncated: false}
+}
+
+func (c *capture) write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := c.limit - c.buf.Len(); n < len(p) {
+		p = p[:max(n, 0)]
+		c.truncated = true
+	}
+	c.buf.Write(p)
+}
+
+// String returns the captured bytes, followed by "..." if the body was truncated.
+func (c *capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated {
+		return c.buf.String() + "..."
+	}
+	return c.buf.String()
+}
+
+// captureReader captures the bytes read from a body and calls onClose when the body is closed.
+type captureReader struct {
+	io.ReadCloser
+
+	capture *capture
+	onClose func()
+}
+
+// Read implements io.Reader.
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.capture.write(p[:n])
+	return n, err //nolint:wrapcheck // the error of the wrapped body
+}
+
+// Close implements io.Closer.
+func (r *captureReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.onClose != nil {
+		r.onClose()
+	}
+	return err //nolint:wrapcheck // the error of the wrapped body
+}
@@ -0,0 +1,25 @@
+// Package helpertest provides logging helpers for the tests of ctxlog.RegisterHelperPackages.
+package helpertest
+
+import (
+	"context"
+
+	"github.com/n-r-w/ctxlog"
+)
+
+// Info logs the message with the logger of the context. It is small enough to be inlined into its callers.
+func Info(ctx context.Context, msg string) {
+	ctxlog.Info(ctx, msg)
+}
+
+// InfoNoInline logs the message with the logger of the context. It is never inlined.
+//
+//go:noinline
+func InfoNoInline(ctx context.Context, msg string) {
+	ctxlog.Info(ctx, msg)
+}
+
+// Nested logs the message with Info.
+func Nested(ctx context.Context, msg string) {
+	Info(ctx, msg)
+}
@@ -6,10 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/n-r-w/ctxlog/serrors"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"go.uber.org/zap"
 	"go.uber.org/zap/exp/zapslog"
@@ -24,12 +29,34 @@ type Logger struct {
 	opts       options
 	zapLogger  *zap.Logger
 	otelLogger *otelzap.Logger
+	state      *loggerState
+}
+
+// loggerState is shared by a logger and all loggers derived from it.
+type loggerState struct {
+	closed    atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+	closers   []func()
+	sampler   atomic.Pointer[sampler]
+	// baseLevel and baseSampler are restored by Logger.Reload when the configuration omits them
+	baseLevel   slog.Level
+	baseSampler *sampler
 }
 
 type options struct {
 	env                EnvType
+	format             Format
+	prettyCompact      bool
+	color              ColorMode
+	preset             FormatPreset
+	gcpProjectID       string
 	level              slog.Leveler
 	addSource          bool
+	stacktraceLevel    slog.Leveler
+	bareErrors         BareErrorMode
+	kindLevels         map[serrors.Kind]slog.Level
+	source             sourceFormat
 	name               string
 	testTB             testing.TB
 	testBuffer         *zaptest.Buffer // Buffer for capturing test output
@@ -38,16 +65,19 @@ type options struct {
 	samplingThereafter int
 	otel               bool
 	timeLayout         string
+	outputPaths        []string
 }
 
 // New creates a new logger.
 // Default: Development mode, Debug level, with call source display.
 func New(opts ...Option) (*Logger, error) {
 	o := options{ //nolint:exhaustruct // default options
-		env:        EnvProduction,
-		level:      slog.LevelDebug,
-		addSource:  true,
-		timeLayout: time.RFC3339Nano,
+		env:             EnvProduction,
+		level:           slog.LevelDebug,
+		addSource:       true,
+		source:          sourceFormat{key: defaultSourceKey}, //nolint:exhaustruct // default options
+		timeLayout:      time.RFC3339Nano,
+		stacktraceLevel: slog.LevelError,
 	}
 	for _, opt := range opts {
 		opt(&o)
@@ -57,25 +87,48 @@ func New(opts ...Option) (*Logger, error) {
 		return nil, err
 	}
 
-	zLevel := zapLevel(o.level)
+	zLevel := zapLevelEnabler{level: o.level}
 
 	var zapConf zap.Config
 	switch o.env {
 	case EnvDevelopment:
 		zapConf = zap.NewDevelopmentConfig()
-		zapConf.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		if o.useColor() {
+			zapConf.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
 	case EnvProduction:
 		zapConf = zap.NewProductionConfig()
 	}
+	o.preset.apply(&zapConf.EncoderConfig)
+	switch o.format {
+	case FormatAuto:
+		if o.preset != PresetNone {
+			zapConf.Encoding = "json"
+		}
+	case FormatConsole:
+		zapConf.Encoding = "console"
+	case FormatJSON:
+		zapConf.Encoding = "json"
+	case FormatLogfmt:
+		zapConf.Encoding = encodingLogfmt
+	case FormatPretty:
+		zapConf.Encoding = encodingPretty
+	}
 	zapConf.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(o.timeLayout)
-	zapConf.Level = zap.NewAtomicLevelAt(zLevel)
-
-	zapLogger, err := zapConf.Build()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create zap logger: %w", err)
+	if len(o.outputPaths) > 0 {
+		zapConf.OutputPaths = o.outputPaths
 	}
 
-	if o.testTB != nil {
+	var (
+		zapLogger  *zap.Logger
+		closeSinks func()
+	)
+	if o.testTB == nil {
+		var err error
+		if zapLogger, closeSinks, err = buildZapLogger(zapConf, o); err != nil {
+			return nil, fmt.Errorf("failed to create zap logger: %w", err)
+		}
+	} else {
 		if o.testBuffer == nil {
 			// General test logger
 			var zo []zap.Option
@@ -99,9 +152,17 @@ func New(opts ...Option) (*Logger, error) {
 				EncodeCaller:   zapcore.ShortCallerEncoder,
 			}
 
+			o.preset.apply(&encConfig)
+
+			encoding := "console"
+			if o.format != FormatAuto || o.preset != PresetNone {
+				encoding = zapConf.Encoding
+			}
+			enc := newEncoder(encoding, encConfig, o)
+
 			// Create test logger with buffer
 			core := zapcore.NewCore(
-				zapcore.NewConsoleEncoder(encConfig),
+				enc,
 				o.testBuffer,
 				zLevel,
 			)
@@ -109,7 +170,37 @@ func New(opts ...Option) (*Logger, error) {
 		}
 	}
 
-	return newLoggerHelper(zapLogger, o), nil
+	l := newLoggerHelper(zapLogger, o)
+	if closeSinks != nil {
+		l.state.closers = append(l.state.closers, closeSinks)
+	}
+
+	return l, nil
+}
+
+const (
+	encodingLogfmt = "logfmt"
+	encodingPretty = "pretty"
+)
+
+// newEncoder creates an encoder for the zap encoding name.
+func newEncoder(encoding string, conf zapcore.EncoderConfig, o options) zapcore.Encoder {
+	switch encoding {
+	case "console":
+		return zapcore.NewConsoleEncoder(conf)
+	case encodingLogfmt:
+		conf.EncodeLevel = zapcore.LowercaseLevelEncoder
+		return newLogfmtEncoder(conf)
+	case encodingPretty:
+		color := o.useColor()
+		conf.EncodeLevel = zapcore.CapitalLevelEncoder
+		if color {
+			conf.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return newPrettyEncoder(conf, o.prettyCompact, color)
+	default:
+		return topLevelEncoder{Encoder: zapcore.NewJSONEncoder(conf)}
+	}
 }
 
 func validateOptions(opts options) error {
@@ -117,38 +208,52 @@ func validateOptions(opts options) error {
 		return errors.New("test buffer is set but test TB is not set")
 	}
 
+	if opts.preset != PresetNone && opts.format != FormatAuto && opts.format != FormatJSON {
+		return fmt.Errorf("format preset %s requires JSON format, got %s", opts.preset, opts.format)
+	}
+
 	return nil
 }
 
 func newLoggerHelper(zapLogger *zap.Logger, opts options) *Logger {
-	core := zapLogger.Core()
-	if opts.samplingTick != 0 {
-		core = zapcore.NewSamplerWithOptions(core, opts.samplingTick, opts.samplingFirst, opts.samplingThereafter)
+	state := &loggerState{} //nolint:exhaustruct // default options
+	var otelLogger *otelzap.Logger
+	if opts.otel {
+		otelLogger = newOtelLogger()
 	}
+	state.baseLevel = opts.level.Level()
+	state.baseSampler = newSampler(opts.samplingTick, opts.samplingFirst, opts.samplingThereafter)
+	state.sampler.Store(state.baseSampler)
+	core := newStackCore(newSamplingCore(zapLogger.Core(), &state.sampler))
 
 	slogLogger := slog.New(
 		newHandler(
 			zapslog.NewHandler(core,
 				zapslog.WithName(opts.name),
 				zapslog.WithCaller(false),
+				// the stack trace is added by the handler starting at the record call site
+				zapslog.AddStacktraceAt(slog.Level(math.MaxInt)),
 			),
-			opts.level,
-			opts.addSource,
+			&handlerConfig{ //nolint:exhaustruct // the source cache is filled on demand
+				level:           opts.level,
+				logSource:       opts.addSource,
+				source:          opts.source,
+				state:           state,
+				bareErrors:      opts.bareErrors,
+				kindLevels:      opts.kindLevels,
+				stacktraceLevel: opts.stacktraceLevel,
+				traceFields:     opts.preset.traceFields(opts.gcpProjectID),
+				otel:            otelLogger,
+			},
 		),
 	)
 
-	l := &Logger{ //nolint:exhaustruct // default options
-		Logger:    slogLogger,
-		opts:      opts,
-		zapLogger: zapLogger,
-	}
-
-	if opts.otel {
-		l.otelLogger = otelzap.New(
-			zapLogger,
-			otelzap.WithStackTrace(true),
-			otelzap.WithTraceIDField(true),
-		)
+	l := &Logger{
+		Logger:     slogLogger,
+		opts:       opts,
+		zapLogger:  zapLogger,
+		otelLogger: otelLogger,
+		state:      state,
 	}
 
 	return l
@@ -165,7 +270,48 @@ func Must(opts ...Option) *Logger {
 
 // Sync flushes buffered log entries. Use it in defer.
 func (l *Logger) Sync() error {
-	if err := l.zapLogger.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+	return syncZap(l.zapLogger)
+}
+
+// Close flushes buffered log entries and releases the output sinks.
+// Flushing is bounded by the context deadline. If the deadline is exceeded, the sinks are released
+// when the flush completes. Close is idempotent:
+// subsequent calls return the result of the first one.
+// After Close, logging through this logger and all loggers derived from it does nothing.
+func (l *Logger) Close(ctx context.Context) error {
+	l.state.closeOnce.Do(func() {
+		l.state.closed.Store(true)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- syncZap(l.zapLogger)
+		}()
+
+		closeSinks := func() {
+			for _, c := range l.state.closers {
+				c()
+			}
+		}
+
+		select {
+		case err := <-done:
+			l.state.closeErr = err
+			closeSinks()
+		case <-ctx.Done():
+			l.state.closeErr = fmt.Errorf("failed to flush logger: %w", ctx.Err())
+			// the sinks are still used by the flush
+			go func() {
+				<-done
+				closeSinks()
+			}()
+		}
+	})
+
+	return l.state.closeErr
+}
+
+func syncZap(zapLogger *zap.Logger) error {
+	if err := zapLogger.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
 		return fmt.Errorf("failed to sync logger: %w", err)
 	}
 
@@ -178,10 +324,7 @@ func (l *Logger) With(args ...any) *Logger {
 		return l
 	}
 
-	return &Logger{ //nolint:exhaustruct // default options
-		Logger:    l.Logger.With(args...),
-		zapLogger: l.zapLogger,
-	}
+	return l.derive(l.Logger.With(args...))
 }
 
 // WithGroup returns a logger that starts a group if name is not empty.
@@ -190,10 +333,15 @@ func (l *Logger) WithGroup(name string) *Logger {
 		return l
 	}
 
-	return &Logger{ //nolint:exhaustruct // default options
-		Logger:    l.Logger.WithGroup(name),
-		zapLogger: l.zapLogger,
-	}
+	return l.derive(l.Logger.WithGroup(name))
+}
+
+// derive returns a copy of the logger with the given slog logger.
+// All other fields are shared with the parent, so every feature keeps working on derived loggers.
+func (l *Logger) derive(slogLogger *slog.Logger) *Logger {
+	d := *l
+	d.Logger = slogLogger
+	return &d
 }
 
 // Debug is implement ILogger interface.
@@ -217,26 +365,55 @@ func (l *Logger) Error(ctx context.Context, msg string, args ...any) {
 }
 
 // LogWithLevel logs a message at the specified level and stack frame skip.
+// The skip set by SetSkipCallStack in the context takes precedence.
 func (l *Logger) LogWithLevel(ctx context.Context, level slog.Level, msg string, skip int, attrs ...any) {
-	if _, ok := GetSkipCallStack(ctx); !ok {
-		// skip the call stack
-		ctx = SetSkipCallStack(ctx, skip)
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	l.Log(ctx, level, msg, attrs...)
+	h := l.Handler()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+
+	if s, ok := GetSkipCallStack(ctx); ok {
+		skip = s
+	}
+
+	// The skip is counted from handler.Handle called by slog.Logger.Log,
+	// the record is built here instead to avoid walking the stack twice.
+	var pcs [1]uintptr
+	runtime.Callers(skip-logWithLevelSkipOffset, pcs[:])
+
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(attrs...)
+	_ = h.Handle(ctx, r)
 }
 
+// logWithLevelSkipOffset is the number of frames between handler.Handle and
+// Logger.LogWithLevel when logging through slog.Logger.Log.
+const logWithLevelSkipOffset = 3
+
 func zapLevel(level slog.Leveler) zapcore.Level {
-	switch level {
-	case slog.LevelDebug:
+	switch l := level.Level(); {
+	case l < slog.LevelInfo:
 		return zap.DebugLevel
-	case slog.LevelInfo:
+	case l < slog.LevelWarn:
 		return zap.InfoLevel
-	case slog.LevelWarn:
+	case l < slog.LevelError:
 		return zap.WarnLevel
-	case slog.LevelError:
-		return zap.ErrorLevel
 	default:
-		return zap.InfoLevel
+		return zap.ErrorLevel
 	}
 }
+
+// zapLevelEnabler makes zap follow the current value of a slog.Leveler,
+// so that a *slog.LevelVar passed to WithLevel can be changed at runtime.
+type zapLevelEnabler struct {
+	level slog.Leveler
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (e zapLevelEnabler) Enabled(l zapcore.Level) bool {
+	return l >= zapLevel(e.level)
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -422,3 +424,180 @@ func TestLogger_Context(t *testing.T) {
 		require.False(t, InContext(ctx), "InContext should return false for context without logger")
 	})
 }
+
+// TestLogger_Close tests the Close method behavior.
+func TestLogger_Close(t *testing.T) {
+	t.Parallel()
+
+	// Create a test buffer to capture output
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(
+		WithEnvType(EnvDevelopment),
+		WithTesting(t),
+		WithTestBuffer(buffer),
+	)
+	require.NoError(t, err)
+
+	ctx := ToContext(context.Background(), logger.With("key", "value"))
+	Info(ctx, "before close")
+
+	require.NoError(t, Close(ctx))
+	require.NoError(t, logger.Close(ctx), "second close should be a no-op")
+
+	// Logging after close must be safe and produce no output
+	logger.Info(ctx, "after close")
+	Info(ctx, "after close")
+
+	output := buffer.String()
+	require.Contains(t, output, "before close")
+	require.NotContains(t, output, "after close")
+}
+
+// blockingSyncer is a zapcore.WriteSyncer whose Sync blocks until released.
+type blockingSyncer struct {
+	release chan struct{}
+}
+
+func (s *blockingSyncer) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (s *blockingSyncer) Sync() error {
+	<-s.release
+	return nil
+}
+
+// TestLogger_CloseTimeout tests that Close respects the context deadline.
+func TestLogger_CloseTimeout(t *testing.T) {
+	t.Parallel()
+
+	syncer := &blockingSyncer{release: make(chan struct{})}
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), syncer, zap.DebugLevel)
+	logger := newLoggerHelper(zap.New(core), options{level: slog.LevelDebug}) //nolint:exhaustruct // default options
+	sinksClosed := make(chan struct{})
+	logger.state.closers = append(logger.state.closers, func() { close(sinksClosed) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := logger.Close(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, logger.Close(context.Background()), context.DeadlineExceeded,
+		"second close should return the result of the first one")
+
+	// the sinks are released only after the flush completes
+	select {
+	case <-sinksClosed:
+		t.Fatal("sinks are closed while the flush is running")
+	default:
+	}
+	close(syncer.release)
+	<-sinksClosed
+}
+
+// TestLogger_WithFormat tests that the WithFormat option overrides the environment format.
+func TestLogger_WithFormat(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(
+		WithEnvType(EnvDevelopment),
+		WithFormat(FormatJSON),
+		WithTesting(t),
+		WithTestBuffer(buffer),
+	)
+	require.NoError(t, err)
+
+	logger.Info(context.Background(), "json message", "key", "value")
+	require.NoError(t, logger.Sync())
+
+	output := buffer.String()
+	require.Contains(t, output, `"msg":"json message"`)
+	require.Contains(t, output, `"key":"value"`)
+}
+
+// TestLogger_Derived tests that derived loggers keep all features of the parent logger.
+func TestLogger_Derived(t *testing.T) {
+	t.Parallel()
+
+	derivations := []struct {
+		name   string
+		derive func(ctx context.Context, l *Logger) *Logger
+	}{
+		{
+			name:   "With",
+			derive: func(_ context.Context, l *Logger) *Logger { return l.With("key", "value") },
+		},
+		{
+			name:   "WithGroup",
+			derive: func(_ context.Context, l *Logger) *Logger { return l.WithGroup("group") },
+		},
+		{
+			name: "context With",
+			derive: func(ctx context.Context, l *Logger) *Logger {
+				return FromContext(With(ToContext(ctx, l), "key", "value"))
+			},
+		},
+		{
+			name: "context WithGroup",
+			derive: func(ctx context.Context, l *Logger) *Logger {
+				return FromContext(WithGroup(ToContext(ctx, l), "group"))
+			},
+		},
+	}
+
+	for _, tt := range derivations {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := Config{Level: "INFO"}.Build( //nolint:exhaustruct // defaults
+				WithEnvType(EnvDevelopment),
+				WithName("derived_logger"),
+				WithSource(true),
+				WithOtelTracing(),
+				WithSampler(time.Minute, 1, 0),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			derived := tt.derive(ctx, logger)
+			require.NotSame(t, logger, derived)
+
+			// Options and OTel logger are preserved
+			require.Equal(t, logger.opts, derived.opts)
+			require.NotNil(t, derived.otelLogger)
+			require.Same(t, logger.otelLogger, derived.otelLogger)
+			require.Same(t, logger.zapLogger, derived.zapLogger)
+			require.Same(t, logger.state, derived.state)
+
+			// Level, name and source are applied
+			derived.Debug(ctx, "filtered message")
+			derived.Info(ctx, "derived message")
+			// Sampling is applied
+			derived.Info(ctx, "derived message")
+
+			// Level handle: reload through the derived logger affects the parent
+			require.NoError(t, derived.Reload(Config{Level: "DEBUG"})) //nolint:exhaustruct // defaults
+			logger.Debug(ctx, "reloaded message")
+
+			// Close through the derived logger closes the parent
+			require.NoError(t, derived.Close(ctx))
+			logger.Info(ctx, "after close")
+
+			output := buffer.String()
+			require.NotContains(t, output, "filtered message")
+			require.Equal(t, 1, strings.Count(output, "derived message"))
+			require.Contains(t, output, "derived_logger")
+			require.Contains(t, output, "log_test.go")
+			require.Contains(t, output, "reloaded message")
+			require.NotContains(t, output, "after close")
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package ctxlog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// logfmtEncoder is a zapcore.Encoder for the logfmt format: level=info msg="some message" key=value.
+// Groups and namespaces are flattened into dotted keys: group.key=value.
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+
+	buf       *buffer.Buffer
+	namespace string // prefix of the keys in the current namespace, e.g. "group."
+}
+
+var _ zapcore.Encoder = (*logfmtEncoder)(nil)
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) *logfmtEncoder {
+	return &logfmtEncoder{
+		EncoderConfig: &cfg,
+		buf:           _pool.Get(),
+		namespace:     "",
+	}
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't affect the original.
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	c := &logfmtEncoder{
+		EncoderConfig: e.EncoderConfig,
+		buf:           _pool.Get(),
+		namespace:     e.namespace,
+	}
+	_, _ = c.buf.Write(e.buf.Bytes())
+	return c
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context, into a byte buffer.
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{
+		EncoderConfig: e.EncoderConfig,
+		buf:           _pool.Get(),
+		namespace:     "",
+	}
+
+	if final.TimeKey != "" && !ent.Time.IsZero() {
+		final.AddTime(final.TimeKey, ent.Time)
+	}
+	if final.LevelKey != "" {
+		final.addKey(final.LevelKey)
+		if final.EncodeLevel != nil {
+			final.appendEncoded(func(enc zapcore.PrimitiveArrayEncoder) { final.EncodeLevel(ent.Level, enc) })
+		} else {
+			appendLogfmtString(final.buf, ent.Level.String())
+		}
+	}
+	if final.NameKey != "" && ent.LoggerName != "" {
+		final.AddString(final.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" {
+			final.addKey(final.CallerKey)
+			if final.EncodeCaller != nil {
+				final.appendEncoded(func(enc zapcore.PrimitiveArrayEncoder) { final.EncodeCaller(ent.Caller, enc) })
+			} else {
+				appendLogfmtString(final.buf, ent.Caller.TrimmedPath())
+			}
+		}
+		if final.FunctionKey != "" {
+			final.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.MessageKey != "" {
+		final.AddString(final.MessageKey, ent.Message)
+	}
+
+	if e.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(' ')
+		}
+		_, _ = final.buf.Write(e.buf.Bytes())
+	}
+
+	final.namespace = e.namespace
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	if final.StacktraceKey != "" && ent.Stack != "" {
+		final.namespace = ""
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+
+	if final.LineEnding != "" {
+		final.buf.AppendString(final.LineEnding)
+	} else {
+		final.buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return final.buf, nil
+}
+
+// AddArray implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	e.addKey(key)
+
+	arr := &logfmtArrayEncoder{enc: e, buf: _pool.Get(), count: 0}
+	defer arr.buf.Free()
+
+	arr.buf.AppendByte('[')
+	err := marshaler.MarshalLogArray(arr)
+	arr.buf.AppendByte(']')
+	appendLogfmtString(e.buf, arr.buf.String())
+
+	return err
+}
+
+// AddObject implements zapcore.ObjectEncoder. Object fields are flattened into dotted keys.
+func (e *logfmtEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	namespace := e.namespace
+	e.OpenNamespace(key)
+	err := marshaler.MarshalLogObject(e)
+	e.namespace = namespace
+
+	return err
+}
+
+// AddBinary implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddBinary(key string, value []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+// AddByteString implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+// AddBool implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddBool(key string, value bool) {
+	e.addKey(key)
+	e.buf.AppendBool(value)
+}
+
+// AddComplex128 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddComplex128(key string, value complex128) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+// AddComplex64 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddComplex64(key string, value complex64) {
+	e.addKey(key)
+	e.buf.AppendString(strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+// AddDuration implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	e.addKey(key)
+	if e.EncodeDuration != nil {
+		e.appendEncoded(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeDuration(value, enc) })
+	} else {
+		e.buf.AppendString(value.String())
+	}
+}
+
+// AddFloat64 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddFloat64(key string, value float64) {
+	e.addKey(key)
+	appendLogfmtFloat(e.buf, value, 64)
+}
+
+// AddFloat32 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddFloat32(key string, value float32) {
+	e.addKey(key)
+	appendLogfmtFloat(e.buf, float64(value), 32)
+}
+
+// AddInt implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddInt(key string, value int) { e.AddInt64(key, int64(value)) }
+
+// AddInt64 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddInt64(key string, value int64) {
+	e.addKey(key)
+	e.buf.AppendInt(value)
+}
+
+// AddInt32 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+
+// AddInt16 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+
+// AddInt8 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddInt8(key string, value int8) { e.AddInt64(key, int64(value)) }
+
+// AddString implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddString(key, value string) {
+	e.addKey(key)
+	appendLogfmtString(e.buf, value)
+}
+
+// AddTime implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddTime(key string, value time.Time) {
+	e.addKey(key)
+	if e.EncodeTime != nil {
+		e.appendEncoded(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeTime(value, enc) })
+	} else {
+		e.buf.AppendTime(value, time.RFC3339Nano)
+	}
+}
+
+// AddUint implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUint(key string, value uint) { e.AddUint64(key, uint64(value)) }
+
+// AddUint64 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUint64(key string, value uint64) {
+	e.addKey(key)
+	e.buf.AppendUint(value)
+}
+
+// AddUint32 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUint32(key string, value uint32) { e.AddUint64(key, uint64(value)) }
+
+// AddUint16 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUint16(key string, value uint16) { e.AddUint64(key, uint64(value)) }
+
+// AddUint8 implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUint8(key string, value uint8) { e.AddUint64(key, uint64(value)) }
+
+// AddUintptr implements zapcore.ObjectEncoder.
+func (e *logfmtEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+// AddReflected implements zapcore.ObjectEncoder. The value is encoded as JSON.
+func (e *logfmtEncoder) AddReflected(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.AddByteString(key, data)
+	return nil
+}
+
+// OpenNamespace implements zapcore.ObjectEncoder.
+// All subsequent fields are prefixed with the namespace key.
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.namespace += sanitizeLogfmtKey(key) + "."
+}
+
+func (e *logfmtEncoder) addKey(key string) {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+	e.buf.AppendString(e.namespace)
+	e.buf.AppendString(sanitizeLogfmtKey(key))
+	e.buf.AppendByte('=')
+}
+
+// appendEncoded appends a single value produced by one of the EncoderConfig encoders.
+func (e *logfmtEncoder) appendEncoded(encode func(zapcore.PrimitiveArrayEncoder)) {
+	arr := &logfmtArrayEncoder{enc: e, buf: _pool.Get(), count: 0}
+	defer arr.buf.Free()
+
+	encode(arr)
+	_, _ = e.buf.Write(arr.buf.Bytes())
+}
+
+// logfmtArrayEncoder encodes array elements separated by commas.
+type logfmtArrayEncoder struct {
+	enc   *logfmtEncoder
+	buf   *buffer.Buffer
+	count int
+}
+
+var _ zapcore.ArrayEncoder = (*logfmtArrayEncoder)(nil)
+
+func (a *logfmtArrayEncoder) next() {
+	if a.count > 0 {
+		a.buf.AppendByte(',')
+	}
+	a.count++
+}
+
+func (a *logfmtArrayEncoder) AppendBool(v bool) { a.next(); a.buf.AppendBool(v) }
+
+func (a *logfmtArrayEncoder) AppendByteString(v []byte) { a.AppendString(string(v)) }
+
+func (a *logfmtArrayEncoder) AppendComplex128(v complex128) {
+	a.next()
+	a.buf.AppendString(strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (a *logfmtArrayEncoder) AppendComplex64(v complex64) {
+	a.next()
+	a.buf.AppendString(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (a *logfmtArrayEncoder) AppendFloat64(v float64) { a.next(); appendLogfmtFloat(a.buf, v, 64) }
+
+func (a *logfmtArrayEncoder) AppendFloat32(v float32) {
+	a.next()
+	appendLogfmtFloat(a.buf, float64(v), 32)
+}
+
+func (a *logfmtArrayEncoder) AppendInt(v int) { a.AppendInt64(int64(v)) }
+
+func (a *logfmtArrayEncoder) AppendInt64(v int64) { a.next(); a.buf.AppendInt(v) }
+
+func (a *logfmtArrayEncoder) AppendInt32(v int32) { a.AppendInt64(int64(v)) }
+
+func (a *logfmtArrayEncoder) AppendInt16(v int16) { a.AppendInt64(int64(v)) }
+
+func (a *logfmtArrayEncoder) AppendInt8(v int8) { a.AppendInt64(int64(v)) }
+
+func (a *logfmtArrayEncoder) AppendString(v string) { a.next(); appendLogfmtString(a.buf, v) }
+
+func (a *logfmtArrayEncoder) AppendUint(v uint) { a.AppendUint64(uint64(v)) }
+
+func (a *logfmtArrayEncoder) AppendUint64(v uint64) { a.next(); a.buf.AppendUint(v) }
+
+func (a *logfmtArrayEncoder) AppendUint32(v uint32) { a.AppendUint64(uint64(v)) }
+
+func (a *logfmtArrayEncoder) AppendUint16(v uint16) { a.AppendUint64(uint64(v)) }
+
+func (a *logfmtArrayEncoder) AppendUint8(v uint8) { a.AppendUint64(uint64(v)) }
+
+func (a *logfmtArrayEncoder) AppendUintptr(v uintptr) { a.AppendUint64(uint64(v)) }
+
+func (a *logfmtArrayEncoder) AppendDuration(v time.Duration) {
+	if a.enc.EncodeDuration != nil {
+		a.enc.EncodeDuration(v, a)
+		return
+	}
+	a.AppendString(v.String())
+}
+
+func (a *logfmtArrayEncoder) AppendTime(v time.Time) {
+	if a.enc.EncodeTime != nil {
+		a.enc.EncodeTime(v, a)
+		return
+	}
+	a.AppendString(v.Format(time.RFC3339Nano))
+}
+
+func (a *logfmtArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	a.next()
+
+	nested := &logfmtArrayEncoder{enc: a.enc, buf: a.buf, count: 0}
+	a.buf.AppendByte('[')
+	err := v.MarshalLogArray(nested)
+	a.buf.AppendByte(']')
+
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	a.next()
+
+	nested := &logfmtEncoder{EncoderConfig: a.enc.EncoderConfig, buf: _pool.Get(), namespace: ""}
+	defer nested.buf.Free()
+
+	err := v.MarshalLogObject(nested)
+	a.buf.AppendByte('{')
+	_, _ = a.buf.Write(nested.buf.Bytes())
+	a.buf.AppendByte('}')
+
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendReflected(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	a.AppendByteString(data)
+	return nil
+}
+
+// appendLogfmtString appends the value, quoting it if necessary.
+func appendLogfmtString(buf *buffer.Buffer, s string) {
+	if !logfmtNeedsQuoting(s) {
+		buf.AppendString(s)
+		return
+	}
+
+	buf.AppendString(strconv.Quote(s))
+}
+
+func logfmtNeedsQuoting(s string) bool {
+	return s == "" || strings.ContainsFunc(s, isLogfmtSpecialRune)
+}
+
+// sanitizeLogfmtKey replaces characters that are not allowed in logfmt keys.
+func sanitizeLogfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	if !strings.ContainsFunc(key, isLogfmtSpecialRune) {
+		return key
+	}
+
+	return strings.Map(func(r rune) rune {
+		if isLogfmtSpecialRune(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// isLogfmtSpecialRune reports whether the rune is not allowed in keys and unquoted values.
+func isLogfmtSpecialRune(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r)
+}
+
+func appendLogfmtFloat(buf *buffer.Buffer, v float64, bitSize int) {
+	switch {
+	case math.IsNaN(v):
+		buf.AppendString("NaN")
+	case math.IsInf(v, 1):
+		buf.AppendString("+Inf")
+	case math.IsInf(v, -1):
+		buf.AppendString("-Inf")
+	default:
+		buf.AppendFloat(v, bitSize)
+	}
+}
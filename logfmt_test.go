@@ -0,0 +1,73 @@
+package ctxlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestLogger_Logfmt verifies the logfmt output format.
+func TestLogger_Logfmt(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	const layout = "2006-01-02 15:04:05"
+	logger, err := New(
+		WithFormat(FormatLogfmt),
+		WithTimeLayout(layout),
+		WithName("fmt"),
+		WithSource(false),
+		WithTesting(t),
+		WithTestBuffer(buffer),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logger.WithGroup("req").With("id", 7).Info(ctx, "hello world",
+		"plain", "value",
+		"quoted", `say "hi"`,
+		"multi", "a\nb",
+		"empty", "",
+		"bad key", "x=y",
+		slog.Group("user", slog.String("name", "bob"), slog.Int("age", 42)),
+		"dur", 1500*time.Millisecond,
+		"err", errors.New("boom"),
+	)
+	require.NoError(t, logger.Sync())
+
+	output := strings.TrimSpace(buffer.String())
+	require.NotContains(t, output, "\n", "record must be a single line")
+
+	timestamp, rest, ok := strings.Cut(output, " ")
+	require.True(t, ok)
+	require.True(t, strings.HasPrefix(timestamp, "time="))
+	// the layout contains a space, so the value is quoted
+	timeValue := strings.TrimPrefix(timestamp, "time=") + " " + strings.SplitN(rest, " ", 2)[0]
+	_, err = time.Parse(layout, strings.Trim(timeValue, `"`))
+	require.NoError(t, err, "timestamp should be in the custom layout, got %q", timeValue)
+
+	for _, want := range []string{
+		` level=info `,
+		` logger=fmt `,
+		` msg="hello world" `,
+		` req.id=7 `,
+		` req.plain=value `,
+		` req.quoted="say \"hi\"" `,
+		` req.multi="a\nb" `,
+		` req.empty="" `,
+		` req.bad_key="x=y" `,
+		` req.user.name=bob `,
+		` req.user.age=42 `,
+		` req.dur=1.5s `,
+		` req.err=boom`,
+	} {
+		require.Contains(t, output, want)
+	}
+}
@@ -3,9 +3,11 @@ package ctxlog
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/n-r-w/ctxlog/serrors"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -36,6 +38,98 @@ func EnvTypeFromString(s string) (EnvType, error) {
 	return EnvDevelopment, fmt.Errorf("unknown environment type: %s", s)
 }
 
+// String returns the name of the environment mode.
+func (e EnvType) String() string {
+	switch e {
+	case EnvDevelopment:
+		return "DEVELOPMENT"
+	case EnvProduction:
+		return "PRODUCTION"
+	}
+
+	return fmt.Sprintf("EnvType(%d)", int(e))
+}
+
+// Set implements flag.Value. The value is parsed case-insensitively by EnvTypeFromString.
+func (e *EnvType) Set(s string) error {
+	env, err := EnvTypeFromString(strings.ToUpper(strings.TrimSpace(s)))
+	if err != nil {
+		return err
+	}
+	*e = env
+	return nil
+}
+
+// Format is a log output format.
+type Format int
+
+const (
+	// FormatAuto (default) selects the format by the environment mode:
+	// FormatConsole for EnvDevelopment and FormatJSON for EnvProduction.
+	FormatAuto Format = iota
+
+	// FormatConsole is a human-readable tab-separated format.
+	FormatConsole
+
+	// FormatJSON is a JSON format.
+	FormatJSON
+
+	// FormatLogfmt is a logfmt format: level=info msg="some message" key=value.
+	// Groups are flattened into dotted keys.
+	FormatLogfmt
+
+	// FormatPretty is a human-readable multi-line format for development:
+	// the message line followed by indented, aligned key/value lines with expanded groups.
+	// Multi-line values and stack traces are printed verbatim. See WithPrettyCompact.
+	FormatPretty
+)
+
+// FormatFromString returns the Format for a case-insensitive string: "AUTO", "CONSOLE", "JSON", "LOGFMT" or "PRETTY".
+func FormatFromString(s string) (Format, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "AUTO":
+		return FormatAuto, nil
+	case "CONSOLE":
+		return FormatConsole, nil
+	case "JSON":
+		return FormatJSON, nil
+	case "LOGFMT":
+		return FormatLogfmt, nil
+	case "PRETTY":
+		return FormatPretty, nil
+	}
+
+	return FormatAuto, fmt.Errorf("unknown log format: %q (valid: AUTO, CONSOLE, JSON, LOGFMT, PRETTY)", s)
+}
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "AUTO"
+	case FormatConsole:
+		return "CONSOLE"
+	case FormatJSON:
+		return "JSON"
+	case FormatLogfmt:
+		return "LOGFMT"
+	case FormatPretty:
+		return "PRETTY"
+	}
+
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Set implements flag.Value.
+func (f *Format) Set(s string) error {
+	format, err := FormatFromString(s)
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
 // Option is a function for configuring the logger.
 type Option func(*options)
 
@@ -47,6 +141,49 @@ func WithEnvType(env EnvType) Option {
 	}
 }
 
+// WithFormat sets the output format.
+// default: FormatAuto.
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithColor sets the color mode of the console and pretty formats.
+// default: ColorAuto.
+func WithColor(mode ColorMode) Option {
+	return func(o *options) {
+		o.color = mode
+	}
+}
+
+// WithPrettyCompact enables the compact mode of FormatPretty:
+// single-line attributes are printed on the message line as key=value pairs.
+// default: false.
+func WithPrettyCompact(compact bool) Option {
+	return func(o *options) {
+		o.prettyCompact = compact
+	}
+}
+
+// WithFormatPreset configures the JSON output for a log backend, see FormatPreset.
+// The preset requires FormatAuto or FormatJSON and selects JSON output in any environment mode.
+// Trace fields are added when the context contains a valid OpenTelemetry span context.
+// default: PresetNone.
+func WithFormatPreset(preset FormatPreset) Option {
+	return func(o *options) {
+		o.preset = preset
+	}
+}
+
+// WithGCPProjectID sets the Google Cloud project ID used by PresetGCP to format the trace field.
+// default: empty string.
+func WithGCPProjectID(projectID string) Option {
+	return func(o *options) {
+		o.gcpProjectID = projectID
+	}
+}
+
 // WithLevel sets the minimum logging level.
 // default: LevelDebug.
 func WithLevel(level slog.Leveler) Option {
@@ -71,6 +208,72 @@ func WithSource(b bool) Option {
 	}
 }
 
+// WithSourceKey sets the key of the source attribute.
+// default: "source".
+func WithSourceKey(key string) Option {
+	return func(o *options) {
+		o.source.key = key
+	}
+}
+
+// WithSourcePath sets the format of the file path in the source attribute.
+// default: SourcePathTrimmed.
+func WithSourcePath(format SourcePathFormat) Option {
+	return func(o *options) {
+		o.source.path = format
+	}
+}
+
+// WithSourceFunction adds the function name to the source attribute: "pkg/file.go:12 pkg.Func".
+// default: false.
+func WithSourceFunction(b bool) Option {
+	return func(o *options) {
+		o.source.function = b
+	}
+}
+
+// WithSourceGroup emits the source as a group with "file", "line" and "function" attributes
+// for backends that index them separately.
+// default: false.
+func WithSourceGroup(b bool) Option {
+	return func(o *options) {
+		o.source.group = b
+	}
+}
+
+// WithStacktraceLevel sets the minimum level of records with a stack trace.
+// The stack starts at the call site of the record. If an error attribute carries a stack
+// (has a StackTrace() []uintptr method), that stack is used instead.
+// A nil level disables stack traces.
+// default: slog.LevelError.
+func WithStacktraceLevel(level slog.Leveler) Option {
+	return func(o *options) {
+		o.stacktraceLevel = level
+	}
+}
+
+// WithBareErrors sets how error arguments without a key are logged,
+// e.g. err in ctxlog.Error(ctx, "failed", err).
+// default: BareErrorAny.
+func WithBareErrors(mode BareErrorMode) Option {
+	return func(o *options) {
+		o.bareErrors = mode
+	}
+}
+
+// WithErrorKindLevel sets the level of records with a logged error of the kind (see serrors.KindOf),
+// e.g. Info for serrors.KindNotFound instead of Error. The level replaces the level of the logging call,
+// records below the minimum level of the logger are dropped.
+// default: the level of the logging call.
+func WithErrorKindLevel(kind serrors.Kind, level slog.Level) Option {
+	return func(o *options) {
+		if o.kindLevels == nil {
+			o.kindLevels = make(map[serrors.Kind]slog.Level)
+		}
+		o.kindLevels[kind] = level
+	}
+}
+
 // WithSampler sets the sampler for the logger.
 func WithSampler(tick time.Duration, first, thereafter int) Option {
 	return func(o *options) {
@@ -80,7 +283,8 @@ func WithSampler(tick time.Duration, first, thereafter int) Option {
 	}
 }
 
-// WithOtelTracing sets up the logger to use OpenTelemetry.
+// WithOtelTracing sets up the logger to use OpenTelemetry: records at slog.LevelWarn and above
+// are added as "log" events to the recording span of the context, error records set the error status of the span.
 // default: false.
 func WithOtelTracing() Option {
 	return func(o *options) {
@@ -103,6 +307,15 @@ func WithTimeLayout(layout string) Option {
 	}
 }
 
+// WithOutputPaths sets the output sinks: file paths or URLs supported by zap.Open
+// (e.g. "stdout", "stderr", "/var/log/app.log").
+// default: stderr.
+func WithOutputPaths(paths ...string) Option {
+	return func(o *options) {
+		o.outputPaths = paths
+	}
+}
+
 // WithTestBuffer sets a buffer for capturing test output.
 // This is useful for testing log output.
 // Requires the WithTesting option to be set.
@@ -0,0 +1,43 @@
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newOtelLogger creates the logger that adds records as events to the span of the context.
+// The records are written to the outputs by the zap logger, so the otelzap one only records the span events.
+func newOtelLogger() *otelzap.Logger {
+	return otelzap.New(zap.NewNop(),
+		// the source is added to the record attributes by the handler
+		otelzap.WithCaller(false),
+	)
+}
+
+// otelEvent adds the record as an event to the recording span of the context.
+// otelzap skips records below zap.WarnLevel and sets the error status of the span for errors.
+func otelEvent(ctx context.Context, l *otelzap.Logger, r slog.Record) {
+	level := zapLevel(r.Level)
+	if level < zapcore.WarnLevel || !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+
+	fields := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != "" {
+			fields = append(fields, zap.Any(a.Key, a.Value.Resolve().Any()))
+		}
+		return true
+	})
+
+	if level == zapcore.WarnLevel {
+		l.Ctx(ctx).Warn(r.Message, fields...)
+		return
+	}
+	l.Ctx(ctx).Error(r.Message, fields...)
+}
@@ -0,0 +1,103 @@
+package ctxlog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingSpan is a span that records its events and status.
+type recordingSpan struct {
+	noop.Span
+
+	mu     sync.Mutex
+	events map[string][]attribute.KeyValue
+	status codes.Code
+}
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) AddEvent(name string, opts ...trace.EventOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := trace.NewEventConfig(opts...)
+	attrs := config.Attributes()
+	for _, a := range attrs {
+		if a.Key == "log.message" {
+			s.events[a.Value.AsString()] = append(attrs, attribute.String("event", name))
+		}
+	}
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = code
+}
+
+// attr returns the value of the attribute of the event with the message.
+func (s *recordingSpan) attr(msg string, key attribute.Key) (attribute.Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.events[msg] {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// TestLogger_OtelTracing verifies that records are added as events to the span of the context.
+func TestLogger_OtelTracing(t *testing.T) {
+	t.Parallel()
+
+	span := &recordingSpan{events: map[string][]attribute.KeyValue{}} //nolint:exhaustruct // defaults
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	logger := NewTest(t, WithOtelTracing())
+	logger.Info(ctx, "info message")
+	logger.Warn(ctx, "warn message", "user", "alice")
+	logger.With("derived", true).Error(ctx, "error message", Err(errors.New("boom")))
+
+	_, ok := span.attr("info message", "log.severity")
+	require.False(t, ok, "records below warn are not added")
+
+	v, ok := span.attr("warn message", "event")
+	require.True(t, ok)
+	require.Equal(t, "log", v.AsString())
+	v, ok = span.attr("warn message", "log.severity")
+	require.True(t, ok)
+	require.Equal(t, "WARN", v.AsString())
+	v, ok = span.attr("warn message", "user")
+	require.True(t, ok)
+	require.Equal(t, "alice", v.AsString())
+
+	_, ok = span.attr("error message", "log.severity")
+	require.True(t, ok)
+	require.Equal(t, codes.Error, span.status)
+}
+
+// TestLogger_OtelTracingDisabled verifies that records are not added to the span without WithOtelTracing.
+func TestLogger_OtelTracingDisabled(t *testing.T) {
+	t.Parallel()
+
+	span := &recordingSpan{events: map[string][]attribute.KeyValue{}} //nolint:exhaustruct // defaults
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	logger := NewTest(t)
+	logger.Error(ctx, "error message")
+
+	_, ok := span.attr("error message", "log.severity")
+	require.False(t, ok)
+	require.Equal(t, codes.Unset, span.status)
+}
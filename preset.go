@@ -0,0 +1,213 @@
+package ctxlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// FormatPreset configures the JSON output for a specific log backend:
+// key names, level values and trace ID formatting.
+type FormatPreset int
+
+const (
+	// PresetNone (default) uses the zap key names.
+	PresetNone FormatPreset = iota
+
+	// PresetGCP is for Google Cloud Logging: "severity", "message",
+	// "logging.googleapis.com/trace" and "logging.googleapis.com/spanId".
+	// Use WithGCPProjectID to format the trace as "projects/PROJECT_ID/traces/TRACE_ID".
+	PresetGCP
+
+	// PresetECS is for Elastic Common Schema: "@timestamp", "log.level", "message",
+	// "error.stack_trace", "trace.id" and "span.id".
+	PresetECS
+
+	// PresetDatadog is for Datadog: "status", "message", "timestamp",
+	// "dd.trace_id" and "dd.span_id" in the decimal Datadog format.
+	PresetDatadog
+)
+
+// String returns the name of the preset.
+func (p FormatPreset) String() string {
+	switch p {
+	case PresetNone:
+		return "NONE"
+	case PresetGCP:
+		return "GCP"
+	case PresetECS:
+		return "ECS"
+	case PresetDatadog:
+		return "DATADOG"
+	}
+
+	return fmt.Sprintf("FormatPreset(%d)", int(p))
+}
+
+// apply sets the encoder keys and level encoding of the preset.
+func (p FormatPreset) apply(c *zapcore.EncoderConfig) {
+	switch p {
+	case PresetNone:
+	case PresetGCP:
+		c.TimeKey = "time"
+		c.LevelKey = "severity"
+		c.EncodeLevel = gcpLevelEncoder
+		c.MessageKey = "message"
+		c.NameKey = "logger"
+		c.StacktraceKey = "stack_trace"
+	case PresetECS:
+		c.TimeKey = "@timestamp"
+		c.LevelKey = "log.level"
+		c.EncodeLevel = zapcore.LowercaseLevelEncoder
+		c.MessageKey = "message"
+		c.NameKey = "log.logger"
+		c.StacktraceKey = "error.stack_trace"
+	case PresetDatadog:
+		c.TimeKey = "timestamp"
+		c.LevelKey = "status"
+		c.EncodeLevel = zapcore.LowercaseLevelEncoder
+		c.MessageKey = "message"
+		c.NameKey = "logger.name"
+		c.StacktraceKey = "error.stack"
+	}
+}
+
+// traceFields returns a function that converts a span context into the preset trace fields,
+// nil if the preset has no trace fields.
+func (p FormatPreset) traceFields(gcpProjectID string) func(trace.SpanContext) []zapcore.Field {
+	switch p {
+	case PresetNone:
+		return nil
+	case PresetGCP:
+		return func(sc trace.SpanContext) []zapcore.Field {
+			traceID := sc.TraceID().String()
+			if gcpProjectID != "" {
+				traceID = "projects/" + gcpProjectID + "/traces/" + traceID
+			}
+			return []zapcore.Field{
+				zap.String("logging.googleapis.com/trace", traceID),
+				zap.String("logging.googleapis.com/spanId", sc.SpanID().String()),
+				zap.Bool("logging.googleapis.com/trace_sampled", sc.IsSampled()),
+			}
+		}
+	case PresetECS:
+		return func(sc trace.SpanContext) []zapcore.Field {
+			return []zapcore.Field{
+				zap.String("trace.id", sc.TraceID().String()),
+				zap.String("span.id", sc.SpanID().String()),
+			}
+		}
+	case PresetDatadog:
+		return func(sc trace.SpanContext) []zapcore.Field {
+			// Datadog uses the lower 64 bits of the trace ID in decimal form
+			traceID := sc.TraceID()
+			spanID := sc.SpanID()
+			return []zapcore.Field{
+				zap.String("dd.trace_id", strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)),
+				zap.String("dd.span_id", strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// gcpLevelEncoder encodes levels as Google Cloud Logging severities.
+func gcpLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	case zapcore.InvalidLevel:
+		enc.AppendString("DEFAULT")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// topLevelFields are fields that are written at the top level of a JSON record,
+// outside of any groups opened with WithGroup.
+type topLevelFields []zapcore.Field
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+// It is used only by encoders that don't support top-level fields.
+func (f topLevelFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i := range f {
+		f[i].AddTo(enc)
+	}
+	return nil
+}
+
+// topLevelEncoder is a JSON encoder that writes topLevelFields at the top level of the record.
+type topLevelEncoder struct {
+	zapcore.Encoder
+}
+
+// Clone implements zapcore.Encoder.
+func (e topLevelEncoder) Clone() zapcore.Encoder {
+	return topLevelEncoder{Encoder: e.Encoder.Clone()}
+}
+
+// EncodeEntry implements zapcore.Encoder.
+func (e topLevelEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	idx := -1
+	for i := range fields {
+		if _, ok := fields[i].Interface.(topLevelFields); ok && fields[i].Type == zapcore.ObjectMarshalerType {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return e.Encoder.EncodeEntry(ent, fields)
+	}
+
+	top, _ := fields[idx].Interface.(topLevelFields)
+	rest := make([]zapcore.Field, 0, len(fields)-1)
+	rest = append(rest, fields[:idx]...)
+	rest = trimEmptyNamespaces(append(rest, fields[idx+1:]...))
+
+	buf, err := e.Encoder.EncodeEntry(ent, rest)
+	if err != nil || len(top) == 0 || buf.Len() == 0 || buf.Bytes()[0] != '{' {
+		return buf, err
+	}
+
+	// encode the top-level fields as a separate JSON object and merge it into the record
+	topBuf, err := zapcore.NewJSONEncoder(zapcore.EncoderConfig{}). //nolint:exhaustruct // only fields are encoded
+									EncodeEntry(zapcore.Entry{}, top) //nolint:exhaustruct // only fields are encoded
+	if err != nil {
+		buf.Free()
+		return nil, err
+	}
+	defer topBuf.Free()
+
+	topObj := bytes.TrimSpace(topBuf.Bytes())
+	record := buf.Bytes()
+
+	out := _pool.Get()
+	out.AppendByte('{')
+	_, _ = out.Write(topObj[1 : len(topObj)-1])
+	if record[1] != '}' {
+		out.AppendByte(',')
+	}
+	_, _ = out.Write(record[1:])
+	buf.Free()
+
+	return out, nil
+}
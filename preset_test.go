@@ -0,0 +1,136 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zaptest"
+)
+
+// TestLogger_FormatPreset verifies key names, level values and trace fields of the format presets.
+func TestLogger_FormatPreset(t *testing.T) {
+	t.Parallel()
+
+	traceID, err := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("1112131415161718")
+	require.NoError(t, err)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct // defaults
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name   string
+		preset FormatPreset
+		opts   []Option
+		want   map[string]any
+	}{
+		{
+			name:   "gcp",
+			preset: PresetGCP,
+			opts:   []Option{WithGCPProjectID("my-project")},
+			want: map[string]any{
+				"severity":                             "WARNING",
+				"message":                              "preset message",
+				"logger":                               "svc",
+				"logging.googleapis.com/trace":         "projects/my-project/traces/0102030405060708090a0b0c0d0e0f10",
+				"logging.googleapis.com/spanId":        "1112131415161718",
+				"logging.googleapis.com/trace_sampled": true,
+			},
+		},
+		{
+			name:   "ecs",
+			preset: PresetECS,
+			want: map[string]any{
+				"log.level":  "warn",
+				"message":    "preset message",
+				"log.logger": "svc",
+				"trace.id":   "0102030405060708090a0b0c0d0e0f10",
+				"span.id":    "1112131415161718",
+			},
+		},
+		{
+			name:   "datadog",
+			preset: PresetDatadog,
+			want: map[string]any{
+				"status":      "warn",
+				"message":     "preset message",
+				"logger.name": "svc",
+				"dd.trace_id": "651345242494996240",
+				"dd.span_id":  "1230066625199609624",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(append(tt.opts,
+				WithEnvType(EnvDevelopment),
+				WithFormatPreset(tt.preset),
+				WithName("svc"),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)...)
+			require.NoError(t, err)
+
+			ctx := trace.ContextWithSpanContext(context.Background(), sc)
+			logger.WithGroup("req").Warn(ctx, "preset message", "key", "value")
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+
+			for k, v := range tt.want {
+				require.Equal(t, v, record[k], "key %q", k)
+			}
+			// trace fields are not placed into the group
+			group, ok := record["req"].(map[string]any)
+			require.True(t, ok)
+			require.Equal(t, "value", group["key"])
+			require.Len(t, group, 2, "group should contain only the attribute and the source")
+		})
+	}
+}
+
+// TestLogger_FormatPresetGroup verifies that the trace fields don't open an empty group.
+func TestLogger_FormatPresetGroup(t *testing.T) {
+	t.Parallel()
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{ //nolint:exhaustruct // defaults
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	})
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(WithFormatPreset(PresetECS), WithSource(false), WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	logger.WithGroup("req").Error(ctx, "preset message")
+	require.NoError(t, logger.Sync())
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+	require.NotContains(t, record, "req")
+	require.Contains(t, record, "trace.id")
+	require.Contains(t, record, "error.stack_trace")
+}
+
+// TestLogger_FormatPresetRequiresJSON verifies that presets can't be combined with non-JSON formats.
+func TestLogger_FormatPresetRequiresJSON(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(WithFormatPreset(PresetGCP), WithFormat(FormatLogfmt))
+	require.Error(t, err)
+}
@@ -0,0 +1,362 @@
+package ctxlog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	prettyIndent = "    "
+	colorKey     = "\x1b[36m"
+	colorReset   = "\x1b[0m"
+	colorStack   = "\x1b[2m"
+)
+
+// prettyEncoder is a zapcore.Encoder for development. It prints the message line
+// followed by indented, aligned key/value lines, expands groups and prints
+// multi-line values and stack traces verbatim.
+// In compact mode single-line attributes are printed on the message line as key=value.
+type prettyEncoder struct {
+	*prettyConfig
+
+	fields    []prettyField
+	namespace []string
+}
+
+type prettyConfig struct {
+	zapcore.EncoderConfig
+
+	compact bool
+	color   bool
+}
+
+// prettyField is an attribute with the path of the groups it belongs to.
+type prettyField struct {
+	groups []string
+	key    string
+	value  string
+}
+
+var _ zapcore.Encoder = (*prettyEncoder)(nil)
+
+func newPrettyEncoder(cfg zapcore.EncoderConfig, compact, color bool) *prettyEncoder {
+	return &prettyEncoder{
+		prettyConfig: &prettyConfig{
+			EncoderConfig: cfg,
+			compact:       compact,
+			color:         color,
+		},
+		fields:    nil,
+		namespace: nil,
+	}
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't affect the original.
+func (e *prettyEncoder) Clone() zapcore.Encoder {
+	return &prettyEncoder{
+		prettyConfig: e.prettyConfig,
+		fields:       slices.Clip(e.fields),
+		namespace:    slices.Clip(e.namespace),
+	}
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context, into a byte buffer.
+func (e *prettyEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final, _ := e.Clone().(*prettyEncoder)
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	buf := _pool.Get()
+
+	header := make([]string, 0, 5) //nolint:mnd // time, level, name, caller, message
+	if final.TimeKey != "" && !ent.Time.IsZero() {
+		if final.EncodeTime != nil {
+			header = append(header, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { final.EncodeTime(ent.Time, enc) }))
+		} else {
+			header = append(header, ent.Time.Format(time.RFC3339Nano))
+		}
+	}
+	if final.LevelKey != "" {
+		if final.EncodeLevel != nil {
+			header = append(header, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { final.EncodeLevel(ent.Level, enc) }))
+		} else {
+			header = append(header, ent.Level.CapitalString())
+		}
+	}
+	if final.NameKey != "" && ent.LoggerName != "" {
+		header = append(header, ent.LoggerName)
+	}
+	if final.CallerKey != "" && ent.Caller.Defined {
+		header = append(header, ent.Caller.TrimmedPath())
+	}
+	if final.MessageKey != "" {
+		header = append(header, ent.Message)
+	}
+	buf.AppendString(strings.Join(header, " "))
+
+	if final.compact {
+		final.renderCompact(buf)
+	} else {
+		final.renderTree(buf)
+	}
+
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.renderMultiline(buf, prettyIndent, final.StacktraceKey, ent.Stack)
+	}
+
+	buf.AppendString(zapcore.DefaultLineEnding)
+
+	return buf, nil
+}
+
+// renderCompact prints single-line attributes on the message line and multi-line ones below.
+func (e *prettyEncoder) renderCompact(buf *buffer.Buffer) {
+	var multiline []prettyField
+	for _, f := range e.fields {
+		key := strings.Join(append(slices.Clip(f.groups), f.key), ".")
+		if strings.Contains(f.value, "\n") {
+			multiline = append(multiline, prettyField{groups: nil, key: key, value: f.value})
+			continue
+		}
+
+		buf.AppendByte(' ')
+		e.appendKey(buf, key)
+		buf.AppendByte('=')
+		appendLogfmtString(buf, f.value)
+	}
+
+	for _, f := range multiline {
+		e.renderMultiline(buf, prettyIndent, f.key, f.value)
+	}
+}
+
+// renderTree prints attributes on separate lines, one line per attribute, with groups expanded.
+func (e *prettyEncoder) renderTree(buf *buffer.Buffer) {
+	var (
+		current []string // groups of the previous field
+		width   int      // key width of the current run of fields with the same groups
+	)
+
+	for i, f := range e.fields {
+		if i == 0 || !slices.Equal(f.groups, current) {
+			// open the groups that differ from the previous field
+			common := 0
+			for common < len(current) && common < len(f.groups) && current[common] == f.groups[common] {
+				common++
+			}
+			for depth := common; depth < len(f.groups); depth++ {
+				buf.AppendByte('\n')
+				buf.AppendString(strings.Repeat(prettyIndent, depth+1))
+				e.appendKey(buf, f.groups[depth])
+				buf.AppendByte(':')
+			}
+			current = f.groups
+			width = e.runKeyWidth(i)
+		}
+
+		indent := strings.Repeat(prettyIndent, len(f.groups)+1)
+		if strings.Contains(f.value, "\n") {
+			e.renderMultiline(buf, indent, f.key, f.value)
+			continue
+		}
+
+		buf.AppendByte('\n')
+		buf.AppendString(indent)
+		e.appendKey(buf, f.key)
+		buf.AppendString(strings.Repeat(" ", width-len(f.key)))
+		buf.AppendString(" = ")
+		buf.AppendString(f.value)
+	}
+}
+
+// runKeyWidth returns the maximum key width of single-line fields
+// that have the same groups as the field at index start and directly follow it.
+func (e *prettyEncoder) runKeyWidth(start int) int {
+	width := 0
+	for _, f := range e.fields[start:] {
+		if !slices.Equal(f.groups, e.fields[start].groups) {
+			break
+		}
+		if !strings.Contains(f.value, "\n") {
+			width = max(width, len(f.key))
+		}
+	}
+	return width
+}
+
+// renderMultiline prints the key followed by the value lines verbatim.
+func (e *prettyEncoder) renderMultiline(buf *buffer.Buffer, indent, key, value string) {
+	buf.AppendByte('\n')
+	buf.AppendString(indent)
+	e.appendKey(buf, key)
+	buf.AppendByte(':')
+
+	for _, line := range strings.Split(strings.TrimRight(value, "\n"), "\n") {
+		buf.AppendByte('\n')
+		buf.AppendString(indent)
+		buf.AppendString(prettyIndent)
+		if e.color && key == e.StacktraceKey {
+			buf.AppendString(colorStack + line + colorReset)
+		} else {
+			buf.AppendString(line)
+		}
+	}
+}
+
+func (e *prettyEncoder) appendKey(buf *buffer.Buffer, key string) {
+	if e.color {
+		buf.AppendString(colorKey + key + colorReset)
+		return
+	}
+	buf.AppendString(key)
+}
+
+func (e *prettyEncoder) add(key, value string) {
+	e.fields = append(e.fields, prettyField{
+		groups: e.namespace,
+		key:    key,
+		value:  value,
+	})
+}
+
+// AddArray implements zapcore.ObjectEncoder. Arrays are printed as JSON.
+func (e *prettyEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := enc.AddArray(key, marshaler); err != nil {
+		return err
+	}
+	return e.AddReflected(key, enc.Fields[key])
+}
+
+// AddObject implements zapcore.ObjectEncoder. Objects are expanded as groups.
+func (e *prettyEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	namespace := e.namespace
+	e.OpenNamespace(key)
+	err := marshaler.MarshalLogObject(e)
+	e.namespace = namespace
+
+	return err
+}
+
+// AddBinary implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddBinary(key string, value []byte) {
+	e.add(key, base64.StdEncoding.EncodeToString(value))
+}
+
+// AddByteString implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddByteString(key string, value []byte) { e.add(key, string(value)) }
+
+// AddBool implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddBool(key string, value bool) { e.add(key, strconv.FormatBool(value)) }
+
+// AddComplex128 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddComplex128(key string, value complex128) {
+	e.add(key, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+// AddComplex64 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddComplex64(key string, value complex64) {
+	e.add(key, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+// AddDuration implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddDuration(key string, value time.Duration) { e.add(key, value.String()) }
+
+// AddFloat64 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddFloat64(key string, value float64) {
+	e.add(key, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// AddFloat32 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddFloat32(key string, value float32) {
+	e.add(key, strconv.FormatFloat(float64(value), 'g', -1, 32))
+}
+
+// AddInt implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddInt(key string, value int) { e.AddInt64(key, int64(value)) }
+
+// AddInt64 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddInt64(key string, value int64) { e.add(key, strconv.FormatInt(value, 10)) }
+
+// AddInt32 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddInt32(key string, value int32) { e.AddInt64(key, int64(value)) }
+
+// AddInt16 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddInt16(key string, value int16) { e.AddInt64(key, int64(value)) }
+
+// AddInt8 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddInt8(key string, value int8) { e.AddInt64(key, int64(value)) }
+
+// AddString implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddString(key, value string) { e.add(key, value) }
+
+// AddTime implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddTime(key string, value time.Time) {
+	if e.EncodeTime != nil {
+		e.add(key, encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeTime(value, enc) }))
+		return
+	}
+	e.add(key, value.Format(time.RFC3339Nano))
+}
+
+// AddUint implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUint(key string, value uint) { e.AddUint64(key, uint64(value)) }
+
+// AddUint64 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUint64(key string, value uint64) {
+	e.add(key, strconv.FormatUint(value, 10))
+}
+
+// AddUint32 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUint32(key string, value uint32) { e.AddUint64(key, uint64(value)) }
+
+// AddUint16 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUint16(key string, value uint16) { e.AddUint64(key, uint64(value)) }
+
+// AddUint8 implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUint8(key string, value uint8) { e.AddUint64(key, uint64(value)) }
+
+// AddUintptr implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) AddUintptr(key string, value uintptr) { e.AddUint64(key, uint64(value)) }
+
+// AddReflected implements zapcore.ObjectEncoder. The value is printed as JSON.
+func (e *prettyEncoder) AddReflected(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.add(key, string(data))
+	return nil
+}
+
+// OpenNamespace implements zapcore.ObjectEncoder.
+func (e *prettyEncoder) OpenNamespace(key string) {
+	namespace := make([]string, len(e.namespace), len(e.namespace)+1)
+	copy(namespace, e.namespace)
+	e.namespace = append(namespace, key)
+}
+
+// encodePrimitive returns the value produced by one of the EncoderConfig encoders as a string.
+func encodePrimitive(encode func(zapcore.PrimitiveArrayEncoder)) string {
+	enc := zapcore.NewMapObjectEncoder()
+	_ = enc.AddArray("v", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		encode(arr)
+		return nil
+	}))
+
+	values, _ := enc.Fields["v"].([]any)
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprint(v))
+	}
+	return strings.Join(parts, " ")
+}
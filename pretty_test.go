@@ -0,0 +1,87 @@
+package ctxlog
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// TestLogger_Pretty verifies the multi-line output of FormatPretty.
+func TestLogger_Pretty(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(
+		WithFormat(FormatPretty),
+		WithName("app"),
+		WithSource(false),
+		WithTesting(t),
+		WithTestBuffer(buffer),
+	)
+	require.NoError(t, err)
+
+	logger.With("id", 5).WithGroup("req").Info(context.Background(), "pretty message",
+		"a", 1,
+		"longer_key", "value",
+		slog.Group("user", slog.String("name", "bob")),
+		"text", "line1\nline2",
+	)
+	require.NoError(t, logger.Sync())
+
+	output := ansiEscape.ReplaceAllString(buffer.String(), "")
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	require.True(t, strings.HasSuffix(lines[0], " INFO app pretty message"), lines[0])
+	require.Equal(t, []string{
+		"    id = 5",
+		"    req:",
+		"        a          = 1",
+		"        longer_key = value",
+		"        user:",
+		"            name = bob",
+		"        text:",
+		"            line1",
+		"            line2",
+	}, lines[1:])
+}
+
+// TestLogger_PrettyCompact verifies the compact mode of FormatPretty.
+func TestLogger_PrettyCompact(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(
+		WithFormat(FormatPretty),
+		WithPrettyCompact(true),
+		WithSource(false),
+		WithTesting(t),
+		WithTestBuffer(buffer),
+	)
+	require.NoError(t, err)
+
+	logger.WithGroup("req").Warn(context.Background(), "compact message",
+		"a", 1,
+		"quoted", "two words",
+		"text", "line1\nline2",
+	)
+	require.NoError(t, logger.Sync())
+
+	output := ansiEscape.ReplaceAllString(buffer.String(), "")
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	require.True(t, strings.HasSuffix(lines[0], ` WARN compact message req.a=1 req.quoted="two words"`), lines[0])
+	require.Equal(t, []string{
+		"    req.text:",
+		"        line1",
+		"        line2",
+	}, lines[1:])
+}
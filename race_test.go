@@ -0,0 +1,6 @@
+//go:build race
+
+package ctxlog
+
+// raceEnabled reports whether the tests are built with the race detector, which adds allocations.
+const raceEnabled = true
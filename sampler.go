@@ -0,0 +1,124 @@
+package ctxlog
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// samplingCore is an analog of zapcore.NewSamplerWithOptions whose settings
+// can be replaced at runtime. Cores derived with With share the settings.
+// Adapted from go.uber.org/zap/zapcore/sampler.go.
+type samplingCore struct {
+	zapcore.Core
+
+	sampler *atomic.Pointer[sampler]
+}
+
+var _ zapcore.Core = samplingCore{} //nolint:exhaustruct // interface check
+
+func newSamplingCore(core zapcore.Core, s *atomic.Pointer[sampler]) zapcore.Core {
+	return samplingCore{
+		Core:    core,
+		sampler: s,
+	}
+}
+
+// With adds structured context to the core.
+func (c samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return newSamplingCore(c.Core.With(fields), c.sampler)
+}
+
+// Check determines whether the entry should be logged.
+func (c samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if s := c.sampler.Load(); s != nil && !s.allow(ent) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+const (
+	samplerMinLevel         = zapcore.DebugLevel
+	samplerMaxLevel         = zapcore.FatalLevel
+	samplerNumLevels        = samplerMaxLevel - samplerMinLevel + 1
+	samplerCountersPerLevel = 4096
+)
+
+// sampler logs the first N entries with a given level and message each tick.
+// If more entries with the same level and message are seen during the same interval,
+// every Mth message is logged and the rest are dropped.
+type sampler struct {
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+	counts     *[samplerNumLevels][samplerCountersPerLevel]samplerCounter
+}
+
+// newSampler returns nil if sampling is disabled.
+func newSampler(tick time.Duration, first, thereafter int) *sampler {
+	if tick <= 0 {
+		return nil
+	}
+
+	return &sampler{
+		tick:       tick,
+		first:      uint64(max(first, 0)),      //nolint:gosec // checked
+		thereafter: uint64(max(thereafter, 0)), //nolint:gosec // checked
+		counts:     &[samplerNumLevels][samplerCountersPerLevel]samplerCounter{},
+	}
+}
+
+func (s *sampler) allow(ent zapcore.Entry) bool {
+	if ent.Level < samplerMinLevel || ent.Level > samplerMaxLevel {
+		return true
+	}
+
+	counter := &s.counts[ent.Level-samplerMinLevel][fnv32a(ent.Message)%samplerCountersPerLevel]
+	n := counter.incCheckReset(ent.Time, s.tick)
+
+	return n <= s.first || (s.thereafter != 0 && (n-s.first)%s.thereafter == 0)
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	counter atomic.Uint64
+}
+
+func (c *samplerCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAfter := c.resetAt.Load()
+	if resetAfter > tn {
+		return c.counter.Add(1)
+	}
+
+	c.counter.Store(1)
+
+	newResetAfter := tn + tick.Nanoseconds()
+	if !c.resetAt.CompareAndSwap(resetAfter, newResetAfter) {
+		// We raced with another goroutine trying to reset, and it also reset
+		// the counter to 1, so we need to reincrement the counter.
+		return c.counter.Add(1)
+	}
+
+	return 1
+}
+
+// fnv32a, adapted from "hash/fnv", but without a []byte(string) alloc.
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := range len(s) {
+		hash ^= uint32(s[i])
+		hash *= prime32
+	}
+	return hash
+}
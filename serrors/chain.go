@@ -0,0 +1,408 @@
+package serrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrorChain is the structure of an error: the layers of the chain from the outermost error
+// to the innermost one and the chains of the errors joined at the end of the chain.
+// It implements json.Marshaler and slog.LogValuer.
+type ErrorChain struct {
+	// Message is the full message of the error.
+	Message string
+	// Layers are the errors of the chain that produce messages, outermost first.
+	// The kinds and fields of the errors of WithKind and With are set on the layers they wrap.
+	Layers []ErrorLayer
+	// Branches are the chains of the errors of errors.Join or another error with Unwrap() []error.
+	Branches []*ErrorChain
+}
+
+// ErrorLayer is an error of a chain.
+type ErrorLayer struct {
+	// Message is the own message of the error without the call site prefix and the message of the wrapped error.
+	Message string
+	// Type is the concrete type of the error.
+	Type string
+	// Site is the call site prefix of the error without braces, empty if it has none.
+	Site string
+	// Field is the field path of an error added to Collector, empty if it has none.
+	Field string
+	// Kind is the kind of the error, KindUnknown if it has none.
+	Kind Kind
+	// Fields are the fields of the error, see With.
+	Fields []slog.Attr
+	// Stack is the stack of the error, see SetStackTrace.
+	Stack []uintptr
+}
+
+var (
+	_ json.Marshaler = (*ErrorChain)(nil)
+	_ slog.LogValuer = (*ErrorChain)(nil)
+)
+
+// Chain returns the structure of the error, nil if err is nil.
+func Chain(err error) *ErrorChain {
+	if err == nil {
+		return nil
+	}
+
+	c := &ErrorChain{Message: err.Error(), Layers: nil, Branches: nil}
+
+	var (
+		kind   = KindUnknown
+		fields []slog.Attr
+	)
+	for e := err; e != nil; {
+		var (
+			next     error
+			branches []error
+		)
+		switch u := e.(type) { //nolint:errorlint // the chain is walked explicitly
+		case interface{ Unwrap() error }:
+			next = u.Unwrap()
+		case interface{ Unwrap() []error }:
+			branches = u.Unwrap()
+		}
+
+		// annotations without messages are set on the layer they wrap
+		switch a := e.(type) { //nolint:errorlint // the chain is walked explicitly
+		case *kindError:
+			if kind == KindUnknown {
+				kind = a.kind
+			}
+			e = next
+			continue
+		case *fieldsError:
+			fields = append(fields, a.attrs...)
+			e = next
+			continue
+		}
+
+		layer := ErrorLayer{
+			Message: "",
+			Type:    reflect.TypeOf(e).String(),
+			Site:    "",
+			Field:   "",
+			Kind:    kind,
+			Fields:  fields,
+			Stack:   nil,
+		}
+		if ce, ok := e.(interface{ CallSite() string }); ok { //nolint:errorlint // the chain is walked explicitly
+			layer.Site = ce.CallSite()
+		}
+		if fe, ok := e.(interface{ FieldPath() string }); ok { //nolint:errorlint // the chain is walked explicitly
+			layer.Field = fe.FieldPath()
+		}
+		if st, ok := e.(stackTracer); ok { //nolint:errorlint // the chain is walked explicitly
+			layer.Stack = st.StackTrace()
+		}
+		if ce, ok := e.(*collectedErrors); ok { //nolint:errorlint // the chain is walked explicitly
+			layer.Message = ce.header()
+		} else {
+			layer.Message = ownMessage(e.Error(), layer.Site, next, branches)
+		}
+		c.Layers = append(c.Layers, layer)
+
+		for _, b := range branches {
+			if b != nil {
+				c.Branches = append(c.Branches, Chain(b))
+			}
+		}
+
+		kind, fields = KindUnknown, nil
+		e = next
+	}
+
+	return c
+}
+
+// ownMessage removes the call site prefix and the messages of the wrapped errors from the message.
+func ownMessage(msg, site string, next error, branches []error) string {
+	if site != "" {
+		msg = strings.TrimPrefix(msg, "{"+site+"} ")
+	}
+
+	switch {
+	case next != nil:
+		if inner := next.Error(); strings.HasSuffix(msg, inner) {
+			msg = strings.TrimRight(strings.TrimSuffix(msg, inner), ": ")
+		}
+	case len(branches) > 0:
+		msgs := make([]string, 0, len(branches))
+		for _, b := range branches {
+			if b != nil {
+				msgs = append(msgs, b.Error())
+			}
+		}
+		if msg == strings.Join(msgs, "\n") {
+			msg = ""
+		}
+	}
+
+	return msg
+}
+
+// MarshalJSON implements json.Marshaler:
+//
+//	{"message": "...", "layers": [{"message": "...", "type": "...", "site": "...", "field": "...", "kind": "...",
+//	"fields": {...}, "stack": ["pkg.Func file.go:10", ...]}], "branches": [...]}
+func (c *ErrorChain) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(c.jsonValue())
+}
+
+type jsonChain struct {
+	Message  string       `json:"message"`
+	Layers   []jsonLayer  `json:"layers"`
+	Branches []*jsonChain `json:"branches,omitempty"`
+}
+
+type jsonLayer struct {
+	Message string         `json:"message,omitempty"`
+	Type    string         `json:"type"`
+	Site    string         `json:"site,omitempty"`
+	Field   string         `json:"field,omitempty"`
+	Kind    string         `json:"kind,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	Stack   []string       `json:"stack,omitempty"`
+}
+
+func (c *ErrorChain) jsonValue() *jsonChain {
+	jc := &jsonChain{
+		Message:  c.Message,
+		Layers:   make([]jsonLayer, 0, len(c.Layers)),
+		Branches: nil,
+	}
+
+	for _, l := range c.Layers {
+		jl := jsonLayer{
+			Message: l.Message,
+			Type:    l.Type,
+			Site:    l.Site,
+			Field:   l.Field,
+			Kind:    "",
+			Fields:  attrsMap(l.Fields),
+			Stack:   nil,
+		}
+		if l.Kind != KindUnknown {
+			jl.Kind = l.Kind.String()
+		}
+		if len(l.Stack) > 0 {
+			frames := runtime.CallersFrames(l.Stack)
+			for {
+				frame, more := frames.Next()
+				jl.Stack = append(jl.Stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+				if !more {
+					break
+				}
+			}
+		}
+		jc.Layers = append(jc.Layers, jl)
+	}
+
+	for _, b := range c.Branches {
+		jc.Branches = append(jc.Branches, b.jsonValue())
+	}
+
+	return jc
+}
+
+// attrsMap converts attributes to a map for JSON, groups become nested maps.
+// Values that cannot be marshaled to JSON, such as channels, functions and NaN, are formatted with fmt.Sprint.
+func attrsMap(attrs []slog.Attr) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindGroup:
+			m[a.Key] = attrsMap(v.Group())
+		case slog.KindAny:
+			m[a.Key] = jsonAny(v.Any())
+		case slog.KindDuration:
+			m[a.Key] = v.Duration().String()
+		case slog.KindFloat64:
+			if f := v.Float64(); math.IsNaN(f) || math.IsInf(f, 0) {
+				m[a.Key] = fmt.Sprint(f)
+			} else {
+				m[a.Key] = f
+			}
+		case slog.KindBool, slog.KindInt64, slog.KindString, slog.KindTime, slog.KindUint64, slog.KindLogValuer:
+			m[a.Key] = v.Any()
+		}
+	}
+
+	return m
+}
+
+// jsonAny returns the value if it can be marshaled to JSON, its message for errors and fmt.Sprint otherwise.
+func jsonAny(v any) any {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return v
+}
+
+// LogValue implements slog.LogValuer. The layers and branches are groups with the keys "0", "1", ...
+// The stack is formatted the same way as by zap.
+func (c *ErrorChain) LogValue() slog.Value {
+	if c == nil {
+		return slog.AnyValue(nil)
+	}
+
+	layers := make([]slog.Attr, 0, len(c.Layers))
+	for i, l := range c.Layers {
+		attrs := make([]slog.Attr, 0, 7) //nolint:mnd // the number of layer attributes
+		if l.Message != "" {
+			attrs = append(attrs, slog.String("message", l.Message))
+		}
+		attrs = append(attrs, slog.String("type", l.Type))
+		if l.Site != "" {
+			attrs = append(attrs, slog.String("site", l.Site))
+		}
+		if l.Field != "" {
+			attrs = append(attrs, slog.String("field", l.Field))
+		}
+		if l.Kind != KindUnknown {
+			attrs = append(attrs, slog.String("kind", l.Kind.String()))
+		}
+		if len(l.Fields) > 0 {
+			attrs = append(attrs, slog.Attr{Key: "fields", Value: slog.GroupValue(l.Fields...)})
+		}
+		if len(l.Stack) > 0 {
+			attrs = append(attrs, slog.String("stack", FormatStack(l.Stack)))
+		}
+		layers = append(layers, slog.Attr{Key: strconv.Itoa(i), Value: slog.GroupValue(attrs...)})
+	}
+
+	attrs := []slog.Attr{
+		slog.String("message", c.Message),
+		{Key: "layers", Value: slog.GroupValue(layers...)},
+	}
+	if len(c.Branches) > 0 {
+		branches := make([]slog.Attr, 0, len(c.Branches))
+		for i, b := range c.Branches {
+			branches = append(branches, slog.Any(strconv.Itoa(i), b))
+		}
+		attrs = append(attrs, slog.Attr{Key: "branches", Value: slog.GroupValue(branches...)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue returns the compact structure of err for logs, built from Chain:
+//   - msg: the error message.
+//   - type: the concrete type of the outermost layer.
+//   - field: the field path of the first layer with one, see Collector.
+//   - kind: the kind of the first layer with one.
+//   - chain: the concrete types of the layers, if there are several.
+//   - sites: the call sites of the layers, if there are any.
+//   - stack: the stack of the first layer with one, formatted by FormatStack.
+//   - branches: the branches, rendered the same way with the keys "0", "1", ...
+//
+// ctxlog.Err renders errors this way. LogValue returns a nil value if err is nil.
+func LogValue(err error) slog.Value {
+	if err == nil {
+		return slog.AnyValue(nil)
+	}
+
+	return Chain(err).compactValue()
+}
+
+func (c *ErrorChain) compactValue() slog.Value {
+	var (
+		types []string
+		sites []string
+		field string
+		kind  = KindUnknown
+		stack []uintptr
+	)
+	for _, l := range c.Layers {
+		types = append(types, l.Type)
+		if l.Site != "" {
+			sites = append(sites, l.Site)
+		}
+		if field == "" {
+			field = l.Field
+		}
+		if kind == KindUnknown {
+			kind = l.Kind
+		}
+		if len(stack) == 0 {
+			stack = l.Stack
+		}
+	}
+
+	attrs := make([]slog.Attr, 0, 8) //nolint:mnd // the number of attributes
+	attrs = append(attrs, slog.String("msg", c.Message))
+	if len(types) > 0 {
+		attrs = append(attrs, slog.String("type", types[0]))
+	}
+	if field != "" {
+		attrs = append(attrs, slog.String("field", field))
+	}
+	if kind != KindUnknown {
+		attrs = append(attrs, slog.String("kind", kind.String()))
+	}
+	if len(types) > 1 {
+		attrs = append(attrs, slog.Any("chain", types))
+	}
+	if len(sites) > 0 {
+		attrs = append(attrs, slog.Any("sites", sites))
+	}
+	if len(stack) > 0 {
+		attrs = append(attrs, slog.String("stack", FormatStack(stack)))
+	}
+	if len(c.Branches) > 0 {
+		branches := make([]slog.Attr, 0, len(c.Branches))
+		for i, b := range c.Branches {
+			branches = append(branches, slog.Attr{Key: strconv.Itoa(i), Value: b.compactValue()})
+		}
+		attrs = append(attrs, slog.Attr{Key: "branches", Value: slog.GroupValue(branches...)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// FormatStack formats the stack the same way as zap:
+// the function name followed by the indented file and line for each frame.
+func FormatStack(stack []uintptr) string {
+	var b strings.Builder
+
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(frame.Function)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,199 @@
+package serrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	require.Nil(t, Chain(nil))
+
+	inner := With(NotFound("order %d", 10), "order_id", 10)
+	outer := With(Errorf("load: %w", inner), "shard", "a")
+	innerSite := inner.(*fieldsError).err.(*kindError).err.(*callError).CallSite() //nolint:errorlint // test
+	outerSite := outer.(*fieldsError).err.(*callError).CallSite()                  //nolint:errorlint // test
+
+	c := Chain(fmt.Errorf("handle: %w", outer))
+	require.Equal(t, "handle: "+outer.Error(), c.Message)
+	require.Equal(t, []ErrorLayer{
+		{Message: "handle", Type: "*fmt.wrapError", Site: "", Field: "", Kind: KindUnknown, Fields: nil, Stack: nil},
+		{
+			Message: "load",
+			Type:    "*serrors.callError",
+			Site:    outerSite,
+			Field:   "",
+			Kind:    KindUnknown,
+			Fields:  []slog.Attr{slog.String("shard", "a")},
+			Stack:   nil,
+		},
+		{
+			Message: "order 10",
+			Type:    "*serrors.callError",
+			Site:    innerSite,
+			Field:   "",
+			Kind:    KindNotFound,
+			Fields:  []slog.Attr{slog.Int("order_id", 10)},
+			Stack:   nil,
+		},
+	}, c.Layers)
+	require.Empty(t, c.Branches)
+}
+
+func TestChain_Branches(t *testing.T) {
+	errA := errors.New("a")
+	errB := WithKind(errors.New("b"), KindTimeout)
+
+	c := Chain(fmt.Errorf("wrapped: %w", errors.Join(errA, nil, errB)))
+	require.Equal(t, []string{"wrapped", ""}, layerMessages(c))
+	require.Equal(t, "*errors.joinError", c.Layers[1].Type)
+	require.Len(t, c.Branches, 2)
+	require.Equal(t, []string{"a"}, layerMessages(c.Branches[0]))
+	require.Equal(t, KindTimeout, c.Branches[1].Layers[0].Kind)
+
+	// a message that doesn't end with the wrapped messages is kept
+	c = Chain(fmt.Errorf("%w and %w", errA, errB))
+	require.Equal(t, "a and b", c.Layers[0].Message)
+	require.Len(t, c.Branches, 2)
+}
+
+func TestChain_Stack(t *testing.T) {
+	SetStackTrace(true)
+	defer SetStackTrace(false)
+
+	err := Errorf("wrapped: %w", New("failed"))
+	c := Chain(err)
+	require.Len(t, c.Layers, 2)
+	require.Empty(t, c.Layers[0].Stack)
+	require.NotEmpty(t, c.Layers[1].Stack)
+
+	data, jsonErr := json.Marshal(c)
+	require.NoError(t, jsonErr)
+	require.Contains(t, string(data), `"stack":["github.com/n-r-w/ctxlog/serrors.TestChain_Stack `)
+}
+
+func TestChain_JSON(t *testing.T) {
+	err := With(Conflict("duplicate"), "order_id", 10, slog.Group("user", "name", "alice"))
+	site := err.(*fieldsError).err.(*kindError).err.(*callError).CallSite() //nolint:errorlint // test
+
+	data, jsonErr := json.Marshal(Chain(errors.Join(err, errors.New("other"))))
+	require.NoError(t, jsonErr)
+	require.JSONEq(t, `{
+		"message": "{`+site+`} duplicate\nother",
+		"layers": [{"type": "*errors.joinError"}],
+		"branches": [
+			{
+				"message": "{`+site+`} duplicate",
+				"layers": [{
+					"message": "duplicate",
+					"type": "*serrors.callError",
+					"site": "`+site+`",
+					"kind": "conflict",
+					"fields": {"order_id": 10, "user": {"name": "alice"}}
+				}]
+			},
+			{"message": "other", "layers": [{"message": "other", "type": "*errors.errorString"}]}
+		]
+	}`, string(data))
+
+	data, jsonErr = json.Marshal(Chain(nil))
+	require.NoError(t, jsonErr)
+	require.Equal(t, "null", string(data))
+}
+
+func TestChain_JSONUnsupportedValues(t *testing.T) {
+	err := With(errors.New("failed"),
+		"ch", make(chan int), "fn", func() {}, "nan", math.NaN(), "inf", math.Inf(1),
+		"nested", map[string]any{"ch": make(chan int)}, "number", 1.5,
+	)
+
+	data, jsonErr := json.Marshal(Chain(err))
+	require.NoError(t, jsonErr)
+
+	var v struct {
+		Layers []struct {
+			Fields map[string]any `json:"fields"`
+		} `json:"layers"`
+	}
+	require.NoError(t, json.Unmarshal(data, &v))
+	require.Len(t, v.Layers, 1)
+
+	fields := v.Layers[0].Fields
+	require.IsType(t, "", fields["ch"])
+	require.IsType(t, "", fields["fn"])
+	require.Equal(t, "NaN", fields["nan"])
+	require.Equal(t, "+Inf", fields["inf"])
+	require.IsType(t, "", fields["nested"])
+	require.InDelta(t, 1.5, fields["number"], 0)
+}
+
+func TestChain_LogValue(t *testing.T) {
+	err := With(Conflict("duplicate"), "order_id", 10)
+	site := err.(*fieldsError).err.(*kindError).err.(*callError).CallSite() //nolint:errorlint // test
+
+	var b strings.Builder
+	logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{ //nolint:exhaustruct // defaults
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("failed", "error", Chain(errors.Join(err)))
+
+	require.Equal(t, `msg=failed error.message="{`+site+`} duplicate" error.layers.0.type=*errors.joinError `+
+		`error.branches.0.message="{`+site+`} duplicate" error.branches.0.layers.0.message=duplicate `+
+		`error.branches.0.layers.0.type=*serrors.callError error.branches.0.layers.0.site=`+site+` `+
+		`error.branches.0.layers.0.kind=conflict error.branches.0.layers.0.fields.order_id=10`+"\n", b.String())
+}
+
+func TestLogValue(t *testing.T) {
+	require.True(t, LogValue(nil).Equal(slog.AnyValue(nil)))
+
+	var c Collector
+	_, _, line, _ := runtime.Caller(0)
+	c.AddFieldf("name", "is required")
+	inner := With(NotFound("order %d", 10), "order_id", 10)
+	err := fmt.Errorf("handle: %w", errors.Join(inner, c.Err()))
+	innerSite := inner.(*fieldsError).err.(*kindError).err.(*callError).CallSite() //nolint:errorlint // test
+	fieldSite := "serrors.TestLogValue." + strconv.Itoa(line+1)
+
+	var b strings.Builder
+	logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{ //nolint:exhaustruct // defaults
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+			if a.Key == "msg" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("failed", "error", LogValue(err))
+
+	require.Equal(t, `error.type=*fmt.wrapError error.chain="[*fmt.wrapError *errors.joinError]" `+
+		`error.branches.0.type=*serrors.callError error.branches.0.kind=not_found `+
+		`error.branches.0.sites=[`+innerSite+`] `+
+		`error.branches.1.type=*serrors.collectedErrors error.branches.1.branches.0.type=*serrors.fieldError `+
+		`error.branches.1.branches.0.field=name `+
+		`error.branches.1.branches.0.chain="[*serrors.fieldError *serrors.callError]" `+
+		`error.branches.1.branches.0.sites=[`+fieldSite+`]`+"\n", b.String())
+}
+
+func layerMessages(c *ErrorChain) []string {
+	msgs := make([]string, 0, len(c.Layers))
+	for _, l := range c.Layers {
+		msgs = append(msgs, l.Message)
+	}
+	return msgs
+}
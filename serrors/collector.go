@@ -0,0 +1,165 @@
+package serrors
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Collector accumulates errors, for example the errors of a validation:
+//
+//	var c serrors.Collector
+//	if order.Name == "" {
+//		c.AddFieldf("name", "is required")
+//	}
+//	for i, item := range order.Items {
+//		if item.Price <= 0 {
+//			c.AddFieldf("items["+strconv.Itoa(i)+"].price", "must be positive")
+//		}
+//	}
+//	return c.Err()
+//
+// Each error gets the call site prefix of the caller of the Add method.
+// The zero value is ready to use. Collector is not safe for concurrent use.
+type Collector struct {
+	errs []error
+}
+
+// Add adds err. Add does nothing if err is nil.
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+
+	c.errs = append(c.errs, errorf(callerFuncLvl, "%w", err))
+}
+
+// Addf adds an error created the same way as by Errorf.
+func (c *Collector) Addf(format string, a ...any) {
+	c.errs = append(c.errs, errorf(callerFuncLvl, format, a...))
+}
+
+// AddField adds err for the field path, for example "items[2].price". AddField does nothing if err is nil.
+func (c *Collector) AddField(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	c.errs = append(c.errs, &fieldError{path: path, err: errorf(callerFuncLvl, "%w", err)})
+}
+
+// AddFieldf adds an error created the same way as by Errorf for the field path.
+func (c *Collector) AddFieldf(path, format string, a ...any) {
+	c.errs = append(c.errs, &fieldError{path: path, err: errorf(callerFuncLvl, format, a...)})
+}
+
+// Len returns the number of the added errors.
+func (c *Collector) Len() int {
+	return len(c.errs)
+}
+
+// Err returns an error with the added errors, nil if there are none.
+// The error wraps the added errors with an Unwrap() []error method, so they work with errors.Is and errors.As.
+// The message is the list of the messages of the errors:
+//
+//	2 errors:
+//	  - name: {orders.Validate.12} is required
+//	  - items[2].price: {orders.Validate.16} must be positive
+//
+// When the error is logged, it is rendered by LogValue, the same way as by ctxlog.Err:
+// the added errors are the branches with the keys "0", "1", ... and the "field" attribute of each error.
+func (c *Collector) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+
+	return &collectedErrors{errs: slices.Clone(c.errs)}
+}
+
+// FieldPath returns the field path of the first error added by AddField or AddFieldf in the chain of err,
+// empty if there is none.
+func FieldPath(err error) string {
+	for e := err; e != nil; {
+		if fe, ok := e.(*fieldError); ok { //nolint:errorlint // the chain is walked explicitly
+			return fe.path
+		}
+
+		u, ok := e.(interface{ Unwrap() error }) //nolint:errorlint // the chain is walked explicitly
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+
+	return ""
+}
+
+// fieldError is an error of a field.
+type fieldError struct {
+	path string
+	err  error
+}
+
+// Error implements error.
+func (e *fieldError) Error() string {
+	return e.path + ": " + e.err.Error()
+}
+
+// Unwrap returns the error of the field.
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// FieldPath returns the path of the field.
+func (e *fieldError) FieldPath() string {
+	return e.path
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *fieldError) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
+
+// collectedErrors is the error of Collector.
+type collectedErrors struct {
+	errs []error
+}
+
+// Error implements error.
+func (e *collectedErrors) Error() string {
+	var b strings.Builder
+	b.WriteString(e.header())
+	b.WriteByte(':')
+	for _, err := range e.errs {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// header returns the first line of the message without the colon: "2 errors".
+func (e *collectedErrors) header() string {
+	if len(e.errs) == 1 {
+		return "1 error"
+	}
+
+	return strconv.Itoa(len(e.errs)) + " errors"
+}
+
+// Unwrap returns the collected errors.
+func (e *collectedErrors) Unwrap() []error {
+	return e.errs
+}
+
+// LogValue implements slog.LogValuer, see the package function LogValue.
+func (e *collectedErrors) LogValue() slog.Value {
+	return LogValue(e)
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *collectedErrors) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
@@ -0,0 +1,88 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	require.NoError(t, c.Err())
+
+	errBase := errors.New("base")
+	c.Add(nil)
+	c.AddField("name", nil)
+	require.Zero(t, c.Len())
+
+	_, _, line, _ := runtime.Caller(0)
+	c.AddFieldf("items[2].price", "must be positive")
+	c.Add(errBase)
+	c.AddField("name", WithKind(errors.New("is required"), KindInvalidArgument))
+	c.Addf("total %d", 10)
+	require.Equal(t, 4, c.Len())
+
+	err := c.Err()
+	site := func(n int) string { return "{serrors.TestCollector." + strconv.Itoa(line+n) + "} " }
+	require.Equal(t, "4 errors:\n"+
+		"  - items[2].price: "+site(1)+"must be positive\n"+
+		"  - "+site(2)+"base\n"+
+		"  - name: "+site(3)+"is required\n"+
+		"  - "+site(4)+"total 10", err.Error())
+	require.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	require.ErrorIs(t, err, errBase)
+	require.Equal(t, KindInvalidArgument, KindOf(err))
+
+	var errs interface{ Unwrap() []error }
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs.Unwrap(), 4)
+	require.Equal(t, "items[2].price", FieldPath(errs.Unwrap()[0]))
+	require.Empty(t, FieldPath(errs.Unwrap()[1]))
+
+	// the error doesn't change when more errors are added
+	c.Addf("more")
+	require.Len(t, errs.Unwrap(), 4)
+
+	c = Collector{}
+	c.Addf("single")
+	require.True(t, strings.HasPrefix(c.Err().Error(), "1 error:\n  - {serrors.TestCollector."))
+}
+
+func TestCollector_LogValue(t *testing.T) {
+	var c Collector
+	c.AddFieldf("items[2].price", "must be positive")
+	c.Add(errors.New("base"))
+
+	// log renders the value with the text handler without the time, the level and the message
+	log := func(v any) string {
+		var b strings.Builder
+		logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{ //nolint:exhaustruct // defaults
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		logger.Info("failed", "errors", v)
+		return b.String()
+	}
+
+	out := log(c.Err())
+	require.Contains(t, out, "errors.type=*serrors.collectedErrors")
+	require.Contains(t, out, "errors.branches.0.msg=\"items[2].price: {serrors.TestCollector_LogValue.")
+	require.Contains(t, out, "errors.branches.0.field=items[2].price")
+	require.Contains(t, out, "errors.branches.1.msg=\"{serrors.TestCollector_LogValue.")
+	require.Equal(t, log(LogValue(c.Err())), out)
+
+	chain := Chain(c.Err())
+	require.Equal(t, "2 errors", chain.Layers[0].Message)
+	require.Len(t, chain.Branches, 2)
+	require.Equal(t, "items[2].price", chain.Branches[0].Layers[0].Message)
+}
@@ -0,0 +1,33 @@
+package serrors_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/n-r-w/ctxlog"
+	"github.com/n-r-w/ctxlog/serrors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStackTrace_Logged(t *testing.T) {
+	serrors.SetStackTrace(true)
+	defer serrors.SetStackTrace(false)
+
+	err := serrors.Errorf("failed")
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+	logger, lErr := ctxlog.New(ctxlog.WithFormat(ctxlog.FormatJSON), ctxlog.WithTesting(t), ctxlog.WithTestBuffer(buffer))
+	require.NoError(t, lErr)
+
+	logger.Error(context.Background(), "failed", "error", err)
+	require.NoError(t, logger.Sync())
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+	stack, ok := record["stacktrace"].(string)
+	require.True(t, ok)
+	require.True(t, strings.HasPrefix(stack, "github.com/n-r-w/ctxlog/serrors_test.TestStackTrace_Logged\n\t"), stack)
+}
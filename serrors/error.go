@@ -4,24 +4,35 @@ package serrors
 import (
 	"errors"
 	"fmt"
+	"io"
 	"runtime"
-	"strconv"
-	"strings"
+	"sync/atomic"
 )
 
 const (
 	callerFuncLvl = 3
-	maxFrames     = 4
+	maxFrames     = 64
 )
 
+// stackTraceEnabled enables capturing of the full stack, see SetStackTrace.
+var stackTraceEnabled atomic.Bool //nolint:gochecknoglobals // package-level setting
+
+// SetStackTrace enables or disables capturing of the stack trace by the functions of this package.
+// The stack is captured once per error chain: errors that wrap an error with a stack don't capture it again.
+// The stack is available via the StackTrace() []uintptr method of the error and printed with %+v.
+// Disabled by default.
+func SetStackTrace(enabled bool) {
+	stackTraceEnabled.Store(enabled)
+}
+
 // Errorf creates an error in the format "{pkg.[type.]callerFuncName} " + fmt.Errorf().String().
 func Errorf(format string, a ...any) error {
-	return errorf(callerFuncLvl, maxFrames, format, a...)
+	return errorf(callerFuncLvl, format, a...)
 }
 
 // Error - analog of serrors.Errorf("%w", err).
 func Error(err error) error {
-	return errorf(callerFuncLvl, maxFrames, "%w", err)
+	return errorf(callerFuncLvl, "%w", err)
 }
 
 // Join - analog of errors.Join(err1, err2) with added information about the function call in err2.
@@ -30,28 +41,184 @@ func Join(err1, err2 error) error {
 		return err1
 	}
 
-	return errors.Join(err1, errorf(callerFuncLvl, maxFrames, "%w", err2))
+	return errors.Join(err1, errorf(callerFuncLvl, "%w", err2))
 }
 
 // Joinf - join with formatting.
 func Joinf(err error, format string, a ...any) error {
-	return errors.Join(err, errorf(callerFuncLvl, maxFrames, format, a...))
+	return errors.Join(err, errorf(callerFuncLvl, format, a...))
 }
 
 // New - analog of errors.New().
 func New(msg string) error {
-	return errorf(callerFuncLvl, maxFrames, "%s", msg)
+	return errorf(callerFuncLvl, "%s", msg)
+}
+
+func errorf(callerFuncLvl int, format string, a ...any) error {
+	wrapped := fmt.Errorf(format, a...)
+
+	var (
+		pcs   [maxFrames]uintptr
+		stack []uintptr
+		n     int
+	)
+	if stackTraceEnabled.Load() && !hasStackTrace(wrapped) {
+		n = runtime.Callers(callerFuncLvl, pcs[:])
+		stack = append([]uintptr(nil), pcs[:n]...)
+	} else {
+		n = runtime.Callers(callerFuncLvl, pcs[:1])
+	}
+	if n == 0 {
+		return wrapped
+	}
+
+	// FuncForPC doesn't allocate, unlike CallersFrames; pc-1 is the call instruction
+	fn := runtime.FuncForPC(pcs[0] - 1)
+	if fn == nil {
+		return wrapped
+	}
+	function := fn.Name()
+	file, line := fn.FileLine(pcs[0] - 1)
+
+	e := callError{
+		function: function,
+		site:     "",
+		msg:      wrapped.Error(),
+		err:      nil,
+		stack:    stack,
+	}
+	// an error that is wrapped again in the same function keeps only the inner prefix
+	if !wrapsSameFunction(wrapped, function) {
+		if e.site = formatSite(function, file, line); e.site != "" {
+			e.msg = "{" + e.site + "} " + e.msg
+		}
+	}
+
+	switch u := wrapped.(type) {
+	case interface{ Unwrap() error }:
+		e.err = u.Unwrap()
+	case interface{ Unwrap() []error }:
+		return &callErrors{callError: e, errs: u.Unwrap()}
+	}
+
+	return &e
+}
+
+// callError is an error with the call site prefix "{pkg.[type.]callerFuncName.line}".
+type callError struct {
+	function string
+	site     string // empty if the error has no prefix
+	msg      string
+	err      error
+	stack    []uintptr
+}
+
+// Error implements error.
+func (e *callError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the error wrapped with %w.
+func (e *callError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns the stack captured when the error was created, nil if it was not captured.
+// See SetStackTrace.
+func (e *callError) StackTrace() []uintptr {
+	return e.stack
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *callError) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
 }
 
-//nolint:unparam // maxFrames is kept for possible future use.
-func errorf(callerFuncLvl, maxFrames int, format string, a ...any) error {
-	pc := make([]uintptr, maxFrames)
-	n := runtime.Callers(callerFuncLvl, pc)
-	frames := runtime.CallersFrames(pc[:n])
-	frame, _ := frames.Next()
-	parts := strings.Split(frame.Function, "/")
-	if len(parts) == 0 {
-		return fmt.Errorf(format, a...)
+// callFunction returns the full name of the function that created the error.
+func (e *callError) callFunction() string {
+	return e.function
+}
+
+// CallSite returns the call site of the prefix without braces, empty if the error has no prefix.
+func (e *callError) CallSite() string {
+	return e.site
+}
+
+// callErrors is a callError that wraps several errors with multiple %w verbs.
+type callErrors struct {
+	callError
+
+	errs []error
+}
+
+// Unwrap returns the errors wrapped with %w.
+func (e *callErrors) Unwrap() []error {
+	return e.errs
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *callErrors) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
+
+func format(err error, s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		_, _ = s.Write([]byte(err.Error()))
+		if s.Flag('+') {
+			writeStack(s, StackTrace(err))
+		}
+	case 's':
+		_, _ = s.Write([]byte(err.Error()))
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", err.Error())
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(%T=%s)", verb, err, err.Error())
+	}
+}
+
+// wrapsSameFunction reports whether the nearest error of this package wrapped by err,
+// following errors that wrap a single error, was created in the function.
+func wrapsSameFunction(err error, function string) bool {
+	for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+		if ce, ok := e.(interface{ callFunction() string }); ok { //nolint:errorlint // the chain is walked explicitly
+			return ce.callFunction() == function
+		}
 	}
-	return fmt.Errorf("{"+parts[len(parts)-1]+"."+strconv.Itoa(frame.Line)+"} "+format, a...)
+
+	return false
+}
+
+// stackTracer is implemented by errors with a stack trace.
+type stackTracer interface {
+	StackTrace() []uintptr
+}
+
+// StackTrace returns the first stack trace in the error tree, nil if there is none.
+// Errors with a stack trace have a StackTrace() []uintptr method, see SetStackTrace and FromPanic.
+// errors.As is not used because wrapping errors may have an empty stack.
+func StackTrace(err error) []uintptr {
+	var stack []uintptr
+	Walk(err, func(e error) bool {
+		if st, ok := e.(stackTracer); ok { //nolint:errorlint // the tree is walked by Walk
+			stack = st.StackTrace()
+		}
+		return len(stack) == 0
+	})
+
+	return stack
+}
+
+// hasStackTrace reports whether the error tree has a stack trace.
+func hasStackTrace(err error) bool {
+	return len(StackTrace(err)) > 0
+}
+
+// writeStack writes the stack the same way as zap: "\n" and the stack formatted by FormatStack.
+func writeStack(s fmt.State, stack []uintptr) {
+	if len(stack) == 0 {
+		return
+	}
+
+	_, _ = io.WriteString(s, "\n"+FormatStack(stack))
 }
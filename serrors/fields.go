@@ -0,0 +1,102 @@
+package serrors
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// With returns an error that wraps err and carries key-value fields.
+// The message of the error is not changed. args are parsed the same way as by slog.Logger.Log:
+// key-value pairs or slog.Attr values. With returns nil if err is nil.
+//
+// ctxlog adds the fields of a logged error to the record, see Fields.
+func With(err error, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return &fieldsError{
+		err:   err,
+		attrs: slog.Group("", args...).Value.Group(),
+	}
+}
+
+// Fields returns the fields attached by With to all errors in the tree of err, outer errors first.
+// If a key is attached at several levels, the outermost value is used.
+func Fields(err error) []slog.Attr {
+	var (
+		attrs []slog.Attr
+		seen  map[string]struct{}
+	)
+
+	Walk(err, func(e error) bool {
+		fe, ok := e.(*fieldsError) //nolint:errorlint // the tree is walked explicitly
+		if !ok {
+			return true
+		}
+
+		for _, a := range fe.attrs {
+			if _, dup := seen[a.Key]; dup {
+				continue
+			}
+			if seen == nil {
+				seen = make(map[string]struct{})
+			}
+			seen[a.Key] = struct{}{}
+			attrs = append(attrs, a)
+		}
+		return true
+	})
+
+	return attrs
+}
+
+// fieldsError is an error with key-value fields.
+type fieldsError struct {
+	err   error
+	attrs []slog.Attr
+}
+
+// Error implements error.
+func (e *fieldsError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error with the fields.
+func (e *fieldsError) Unwrap() error {
+	return e.err
+}
+
+// LogAttrs returns the fields of this error, without the fields of the errors it wraps.
+func (e *fieldsError) LogAttrs() []slog.Attr {
+	return e.attrs
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *fieldsError) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
+
+// Walk calls fn for err and the errors it wraps with Unwrap() error and Unwrap() []error,
+// depth first, until fn returns false. Walk returns false if it was stopped.
+func Walk(err error, fn func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+
+	switch u := err.(type) { //nolint:errorlint // the tree is walked explicitly
+	case interface{ Unwrap() error }:
+		return Walk(u.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			if !Walk(e, fn) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
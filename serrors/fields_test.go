@@ -0,0 +1,59 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWith(t *testing.T) {
+	require.NoError(t, With(nil, "key", "value"))
+
+	errBase := errors.New("base")
+	err := With(errBase, "order_id", 10, slog.String("user", "alice"))
+	require.Equal(t, "base", err.Error())
+	require.ErrorIs(t, err, errBase)
+	require.Equal(t, []slog.Attr{slog.Int("order_id", 10), slog.String("user", "alice")}, Fields(err))
+}
+
+func TestFields(t *testing.T) {
+	inner := With(New("not found"), "order_id", 10, "attempt", 1)
+	outer := With(Errorf("load: %w", inner), "attempt", 2, "shard", "a")
+	joined := errors.Join(outer, With(errors.New("other"), "host", "db1"))
+
+	require.Equal(t, []slog.Attr{
+		slog.Int("attempt", 2),
+		slog.String("shard", "a"),
+		slog.Int("order_id", 10),
+		slog.String("host", "db1"),
+	}, Fields(joined))
+
+	require.Empty(t, Fields(New("no fields")))
+	require.Empty(t, Fields(nil))
+	require.Equal(t, outer.Error(), fmt.Sprintf("%+v", outer))
+}
+
+func TestWalk(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+	errC := errors.New("c")
+	err := fmt.Errorf("wrap: %w", errors.Join(errA, fmt.Errorf("inner: %w", errB), errC))
+
+	var msgs []string
+	require.True(t, Walk(err, func(e error) bool {
+		msgs = append(msgs, e.Error())
+		return true
+	}))
+	require.Equal(t, []string{err.Error(), "a\ninner: b\nc", "a", "inner: b", "b", "c"}, msgs)
+
+	// stopped at the first leaf
+	msgs = nil
+	require.False(t, Walk(err, func(e error) bool {
+		msgs = append(msgs, e.Error())
+		return e != errA //nolint:errorlint // the leaf is compared
+	}))
+	require.Equal(t, []string{err.Error(), "a\ninner: b\nc", "a"}, msgs)
+}
@@ -0,0 +1,260 @@
+package serrors
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Kind is a class of errors that maps to HTTP status codes and gRPC codes.
+type Kind int
+
+const (
+	// KindUnknown is the kind of errors without a kind.
+	KindUnknown Kind = iota
+	// KindInvalidArgument is for invalid input: HTTP 400, gRPC InvalidArgument.
+	KindInvalidArgument
+	// KindNotFound is for missing entities: HTTP 404, gRPC NotFound.
+	KindNotFound
+	// KindConflict is for duplicates, such as an already existing entity, and conflicting changes:
+	// HTTP 409, gRPC AlreadyExists.
+	KindConflict
+	// KindUnauthorized is for missing or invalid credentials: HTTP 401, gRPC Unauthenticated.
+	KindUnauthorized
+	// KindPermissionDenied is for insufficient permissions: HTTP 403, gRPC PermissionDenied.
+	KindPermissionDenied
+	// KindResourceExhausted is for exceeded quotas and rate limits: HTTP 429, gRPC ResourceExhausted.
+	KindResourceExhausted
+	// KindTimeout is for exceeded deadlines: HTTP 504, gRPC DeadlineExceeded.
+	KindTimeout
+	// KindUnavailable is for temporarily unavailable services: HTTP 503, gRPC Unavailable.
+	KindUnavailable
+	// KindUnimplemented is for unsupported operations: HTTP 501, gRPC Unimplemented.
+	KindUnimplemented
+	// KindInternal is for internal errors: HTTP 500, gRPC Internal.
+	KindInternal
+)
+
+// gRPC codes, see google.golang.org/grpc/codes.
+const (
+	grpcCodeUnknown           uint32 = 2
+	grpcCodeInvalidArgument   uint32 = 3
+	grpcCodeDeadlineExceeded  uint32 = 4
+	grpcCodeNotFound          uint32 = 5
+	grpcCodeAlreadyExists     uint32 = 6
+	grpcCodePermissionDenied  uint32 = 7
+	grpcCodeResourceExhausted uint32 = 8
+	grpcCodeUnimplemented     uint32 = 12
+	grpcCodeInternal          uint32 = 13
+	grpcCodeUnavailable       uint32 = 14
+	grpcCodeUnauthenticated   uint32 = 16
+)
+
+// String returns the name of the kind in snake case: "not_found".
+func (k Kind) String() string {
+	switch k {
+	case KindUnknown:
+		return "unknown"
+	case KindInvalidArgument:
+		return "invalid_argument"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindPermissionDenied:
+		return "permission_denied"
+	case KindResourceExhausted:
+		return "resource_exhausted"
+	case KindTimeout:
+		return "timeout"
+	case KindUnavailable:
+		return "unavailable"
+	case KindUnimplemented:
+		return "unimplemented"
+	case KindInternal:
+		return "internal"
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// HTTPStatus returns the HTTP status code of the kind, 500 for KindUnknown.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindInvalidArgument:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindPermissionDenied:
+		return http.StatusForbidden
+	case KindResourceExhausted:
+		return http.StatusTooManyRequests
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindUnimplemented:
+		return http.StatusNotImplemented
+	case KindUnknown, KindInternal:
+	}
+
+	return http.StatusInternalServerError
+}
+
+// GRPCCode returns the gRPC code of the kind, Unknown (2) for KindUnknown.
+// Convert it with codes.Code(kind.GRPCCode()) of google.golang.org/grpc/codes.
+func (k Kind) GRPCCode() uint32 {
+	switch k {
+	case KindInvalidArgument:
+		return grpcCodeInvalidArgument
+	case KindNotFound:
+		return grpcCodeNotFound
+	case KindConflict:
+		return grpcCodeAlreadyExists
+	case KindUnauthorized:
+		return grpcCodeUnauthenticated
+	case KindPermissionDenied:
+		return grpcCodePermissionDenied
+	case KindResourceExhausted:
+		return grpcCodeResourceExhausted
+	case KindTimeout:
+		return grpcCodeDeadlineExceeded
+	case KindUnavailable:
+		return grpcCodeUnavailable
+	case KindUnimplemented:
+		return grpcCodeUnimplemented
+	case KindInternal:
+		return grpcCodeInternal
+	case KindUnknown:
+	}
+
+	return grpcCodeUnknown
+}
+
+// KindOf returns the kind of the first error with a kind in the tree of err, outer errors first.
+// It returns KindUnknown if there is none.
+func KindOf(err error) Kind {
+	kind := KindUnknown
+	Walk(err, func(e error) bool {
+		if ke, ok := e.(interface{ ErrorKind() Kind }); ok { //nolint:errorlint // the tree is walked by Walk
+			kind = ke.ErrorKind()
+		}
+		return kind == KindUnknown
+	})
+
+	return kind
+}
+
+// HTTPStatus returns the HTTP status code of the kind of err, see KindOf.
+func HTTPStatus(err error) int {
+	return KindOf(err).HTTPStatus()
+}
+
+// GRPCCode returns the gRPC code of the kind of err, see KindOf.
+func GRPCCode(err error) uint32 {
+	return KindOf(err).GRPCCode()
+}
+
+// WithKind returns an error that wraps err and has the kind.
+// The message of the error is not changed. WithKind returns nil if err is nil.
+func WithKind(err error, kind Kind) error {
+	if err == nil {
+		return nil
+	}
+
+	return &kindError{err: err, kind: kind}
+}
+
+// Kindf is Errorf for an error of the kind.
+func Kindf(kind Kind, format string, a ...any) error {
+	return kindErrorf(kind, format, a...)
+}
+
+// InvalidArgument is Errorf for an error of KindInvalidArgument.
+func InvalidArgument(format string, a ...any) error {
+	return kindErrorf(KindInvalidArgument, format, a...)
+}
+
+// NotFound is Errorf for an error of KindNotFound.
+func NotFound(format string, a ...any) error {
+	return kindErrorf(KindNotFound, format, a...)
+}
+
+// Conflict is Errorf for an error of KindConflict.
+func Conflict(format string, a ...any) error {
+	return kindErrorf(KindConflict, format, a...)
+}
+
+// Unauthorized is Errorf for an error of KindUnauthorized.
+func Unauthorized(format string, a ...any) error {
+	return kindErrorf(KindUnauthorized, format, a...)
+}
+
+// PermissionDenied is Errorf for an error of KindPermissionDenied.
+func PermissionDenied(format string, a ...any) error {
+	return kindErrorf(KindPermissionDenied, format, a...)
+}
+
+// ResourceExhausted is Errorf for an error of KindResourceExhausted.
+func ResourceExhausted(format string, a ...any) error {
+	return kindErrorf(KindResourceExhausted, format, a...)
+}
+
+// Timeout is Errorf for an error of KindTimeout.
+func Timeout(format string, a ...any) error {
+	return kindErrorf(KindTimeout, format, a...)
+}
+
+// Unavailable is Errorf for an error of KindUnavailable.
+func Unavailable(format string, a ...any) error {
+	return kindErrorf(KindUnavailable, format, a...)
+}
+
+// Unimplemented is Errorf for an error of KindUnimplemented.
+func Unimplemented(format string, a ...any) error {
+	return kindErrorf(KindUnimplemented, format, a...)
+}
+
+// Internal is Errorf for an error of KindInternal.
+func Internal(format string, a ...any) error {
+	return kindErrorf(KindInternal, format, a...)
+}
+
+// kindErrorf is errorf for the caller of the caller of kindErrorf.
+func kindErrorf(kind Kind, format string, a ...any) error {
+	return &kindError{
+		err:  errorf(callerFuncLvl+1, format, a...),
+		kind: kind,
+	}
+}
+
+// kindError is an error with a kind.
+type kindError struct {
+	err  error
+	kind Kind
+}
+
+// Error implements error.
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error with the kind.
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+// ErrorKind returns the kind of the error.
+func (e *kindError) ErrorKind() Kind {
+	return e.kind
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *kindError) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
@@ -0,0 +1,91 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		kind     Kind
+		name     string
+		http     int
+		grpcCode uint32
+	}{
+		{kind: KindUnknown, name: "unknown", http: http.StatusInternalServerError, grpcCode: 2},
+		{kind: KindInvalidArgument, name: "invalid_argument", http: http.StatusBadRequest, grpcCode: 3},
+		{kind: KindNotFound, name: "not_found", http: http.StatusNotFound, grpcCode: 5},
+		{kind: KindConflict, name: "conflict", http: http.StatusConflict, grpcCode: 6},
+		{kind: KindUnauthorized, name: "unauthorized", http: http.StatusUnauthorized, grpcCode: 16},
+		{kind: KindPermissionDenied, name: "permission_denied", http: http.StatusForbidden, grpcCode: 7},
+		{kind: KindResourceExhausted, name: "resource_exhausted", http: http.StatusTooManyRequests, grpcCode: 8},
+		{kind: KindTimeout, name: "timeout", http: http.StatusGatewayTimeout, grpcCode: 4},
+		{kind: KindUnavailable, name: "unavailable", http: http.StatusServiceUnavailable, grpcCode: 14},
+		{kind: KindUnimplemented, name: "unimplemented", http: http.StatusNotImplemented, grpcCode: 12},
+		{kind: KindInternal, name: "internal", http: http.StatusInternalServerError, grpcCode: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.name, tt.kind.String())
+			require.Equal(t, tt.http, tt.kind.HTTPStatus())
+			require.Equal(t, tt.grpcCode, tt.kind.GRPCCode())
+
+			err := Error(Kindf(tt.kind, "failed"))
+			require.Equal(t, tt.kind, KindOf(err))
+			require.Equal(t, tt.http, HTTPStatus(err))
+			require.Equal(t, tt.grpcCode, GRPCCode(err))
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	require.Equal(t, KindUnknown, KindOf(nil))
+	require.Equal(t, KindUnknown, KindOf(errors.New("plain")))
+
+	errBase := errors.New("base")
+	err := WithKind(errBase, KindConflict)
+	require.Equal(t, "base", err.Error())
+	require.ErrorIs(t, err, errBase)
+	require.Equal(t, KindConflict, KindOf(fmt.Errorf("wrapped: %w", err)))
+	require.NoError(t, WithKind(nil, KindConflict))
+
+	// the outermost kind wins
+	require.Equal(t, KindTimeout, KindOf(WithKind(NotFound("missing"), KindTimeout)))
+
+	// joined errors
+	require.Equal(t, KindNotFound, KindOf(errors.Join(errors.New("other"), NotFound("missing"))))
+}
+
+func TestKindConstructors(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		new  func(format string, a ...any) error
+	}{
+		{kind: KindInvalidArgument, new: InvalidArgument},
+		{kind: KindNotFound, new: NotFound},
+		{kind: KindConflict, new: Conflict},
+		{kind: KindUnauthorized, new: Unauthorized},
+		{kind: KindPermissionDenied, new: PermissionDenied},
+		{kind: KindResourceExhausted, new: ResourceExhausted},
+		{kind: KindTimeout, new: Timeout},
+		{kind: KindUnavailable, new: Unavailable},
+		{kind: KindUnimplemented, new: Unimplemented},
+		{kind: KindInternal, new: Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			_, _, line, _ := runtime.Caller(0)
+			err := tt.new("order %d", 10)
+			require.Equal(t, tt.kind, KindOf(err))
+			require.Equal(t, "{serrors.TestKindConstructors.func1."+strconv.Itoa(line+1)+"} order 10", err.Error())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Recover converts a panic into an error and stores it in *err. It must be deferred directly:
+//
+//	func (w *Worker) process(job Job) (err error) {
+//		defer serrors.Recover(&err)
+//		...
+//	}
+//
+// The error is created by FromPanic. Recover does nothing if there is no panic.
+func Recover(err *error) {
+	if v := recover(); v != nil {
+		*err = fromPanic(v)
+	}
+}
+
+// FromPanic converts a value returned by recover() into an error, nil if v is nil.
+// The error has the call site prefix of the function that panicked and the message "panic: " + the value.
+// It carries the stack of the goroutine from the panic site, available via the StackTrace() []uintptr
+// method regardless of SetStackTrace, and the value, available via PanicValue.
+// If the value is an error, the error wraps it and works with errors.Is and errors.As.
+func FromPanic(v any) error {
+	if v == nil {
+		return nil
+	}
+
+	return fromPanic(v)
+}
+
+// PanicValue returns the panic value of the first error created by FromPanic or Recover in the tree of err.
+func PanicValue(err error) (any, bool) {
+	var pe *panicError
+	if !errors.As(err, &pe) {
+		return nil, false
+	}
+
+	return pe.value, true
+}
+
+// fromPanic creates the error of FromPanic for the panic site found in the stack of the caller.
+func fromPanic(v any) *panicError {
+	e := &panicError{
+		callError: callError{
+			function: "",
+			site:     "",
+			msg:      "",
+			err:      nil,
+			stack:    panicStack(),
+		},
+		value: v,
+	}
+
+	if err, ok := v.(error); ok {
+		e.err = err
+		e.msg = "panic: " + err.Error()
+	} else {
+		e.msg = fmt.Sprintf("panic: %v", v)
+	}
+
+	if len(e.stack) > 0 {
+		frame, _ := runtime.CallersFrames(e.stack[:1]).Next()
+		e.function = frame.Function
+		if e.site = formatSite(frame.Function, frame.File, frame.Line); e.site != "" {
+			e.msg = "{" + e.site + "} " + e.msg
+		}
+	}
+
+	return e
+}
+
+// panicStack returns the stack from the function that panicked. The frames of the runtime panic handling
+// are skipped. If the caller is not handling a panic, it returns the stack from the caller of FromPanic or Recover.
+func panicStack() []uintptr {
+	var pcs [maxFrames]uintptr
+	// skip runtime.Callers, panicStack, fromPanic and FromPanic or Recover
+	n := runtime.Callers(4, pcs[:]) //nolint:mnd // the frames of this package
+	stack := pcs[:n]
+
+	start := 0
+	inPanic := false
+	for i, pc := range stack {
+		var function string
+		if fn := runtime.FuncForPC(pc - 1); fn != nil {
+			function = fn.Name()
+		}
+
+		switch {
+		case function == "runtime.gopanic":
+			inPanic = true
+			start = i + 1
+		case inPanic && strings.HasPrefix(function, "runtime."):
+			// runtime.panicmem, runtime.sigpanic and others between gopanic and the code that panicked
+			start = i + 1
+		case inPanic:
+			// the nearest panic is the one being recovered, the frames below it can be handling other panics
+			return append([]uintptr(nil), stack[start:]...)
+		}
+	}
+	if start >= len(stack) {
+		start = 0
+	}
+
+	return append([]uintptr(nil), stack[start:]...)
+}
+
+// panicError is an error created from a recovered panic.
+type panicError struct {
+	callError
+
+	value any
+}
+
+// Format implements fmt.Formatter: %+v adds the stack trace of the error chain to the message.
+func (e *panicError) Format(s fmt.State, verb rune) {
+	format(e, s, verb)
+}
@@ -0,0 +1,114 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errPanic = errors.New("panic error")
+
+func panicWith(v any) (line int, err error) {
+	defer Recover(&err)
+
+	_, _, line, _ = runtime.Caller(0)
+	panic(v)
+}
+
+func panicNil() (line int, err error) {
+	defer Recover(&err)
+
+	var m *struct{ n int }
+	_, _, line, _ = runtime.Caller(0)
+	m.n++
+	return line, nil
+}
+
+func panicFromPanic(v any) (line int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = FromPanic(r)
+		}
+	}()
+
+	_, _, line, _ = runtime.Caller(0)
+	panic(v)
+}
+
+func panicNested() (err error) {
+	defer Recover(&err)
+
+	defer func() {
+		_ = recover()
+		panic("second")
+	}()
+
+	panic("first")
+}
+
+func TestRecover(t *testing.T) {
+	line, err := panicWith("boom")
+	require.Equal(t, "{serrors.panicWith."+strconv.Itoa(line+1)+"} panic: boom", err.Error())
+	v, ok := PanicValue(Error(err))
+	require.True(t, ok)
+	require.Equal(t, "boom", v)
+
+	// the stack starts at the panic site
+	st, ok := err.(stackTracer) //nolint:errorlint // test
+	require.True(t, ok)
+	frame, _ := runtime.CallersFrames(st.StackTrace()).Next()
+	require.Equal(t, "github.com/n-r-w/ctxlog/serrors.panicWith", frame.Function)
+	require.Equal(t, line+1, frame.Line)
+
+	line, err = panicWith(fmt.Errorf("wrapped: %w", errPanic))
+	require.Equal(t, "{serrors.panicWith."+strconv.Itoa(line+1)+"} panic: wrapped: panic error", err.Error())
+	require.ErrorIs(t, err, errPanic)
+
+	line, err = panicNil()
+	var runtimeErr runtime.Error
+	require.ErrorAs(t, err, &runtimeErr)
+	require.Contains(t, err.Error(), "{serrors.panicNil."+strconv.Itoa(line+1)+"} panic: runtime error")
+
+	// the site of the panic being recovered
+	err = panicNested()
+	require.Contains(t, err.Error(), "{serrors.panicNested.func1.")
+	require.Contains(t, err.Error(), "} panic: second")
+
+	// no panic
+	err = errPanic
+	func() { defer Recover(&err) }()
+	require.Equal(t, errPanic, err)
+}
+
+func TestFromPanic(t *testing.T) {
+	require.NoError(t, FromPanic(nil))
+
+	_, _, line, _ := runtime.Caller(0)
+	err := FromPanic(10)
+	require.Equal(t, "{serrors.TestFromPanic."+strconv.Itoa(line+1)+"} panic: 10", err.Error())
+	require.Contains(t, fmt.Sprintf("%+v", err), "serrors.TestFromPanic\n\t")
+
+	_, ok := PanicValue(errPanic)
+	require.False(t, ok)
+}
+
+func TestFromPanic_DeferredRecover(t *testing.T) {
+	line, err := panicFromPanic("boom")
+	require.Equal(t, "{serrors.panicFromPanic."+strconv.Itoa(line+1)+"} panic: boom", err.Error())
+	v, ok := PanicValue(err)
+	require.True(t, ok)
+	require.Equal(t, "boom", v)
+
+	// the stack starts at the panic site, not in the deferred closure
+	frame, _ := runtime.CallersFrames(StackTrace(err)).Next()
+	require.Equal(t, "github.com/n-r-w/ctxlog/serrors.panicFromPanic", frame.Function)
+	require.Equal(t, line+1, frame.Line)
+
+	line, err = panicFromPanic(errPanic)
+	require.Equal(t, "{serrors.panicFromPanic."+strconv.Itoa(line+1)+"} panic: panic error", err.Error())
+	require.ErrorIs(t, err, errPanic)
+}
@@ -0,0 +1,83 @@
+package serrors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// PrefixFormat is the format of the call site prefix of error messages.
+type PrefixFormat int32
+
+const (
+	// PrefixFunctionLine (default) is the function and the line: "{pkg.[type.]functionName.line}".
+	PrefixFunctionLine PrefixFormat = iota
+
+	// PrefixFunction is the function without the line: "{pkg.[type.]functionName}".
+	PrefixFunction
+
+	// PrefixFileLine is the file with its directory and the line: "{pkg/file.go:line}".
+	PrefixFileLine
+
+	// PrefixNone disables prefixes. Errors still record the stack if it is enabled by SetStackTrace.
+	PrefixNone
+)
+
+// String returns the name of the prefix format.
+func (f PrefixFormat) String() string {
+	switch f {
+	case PrefixFunctionLine:
+		return "FUNCTION_LINE"
+	case PrefixFunction:
+		return "FUNCTION"
+	case PrefixFileLine:
+		return "FILE_LINE"
+	case PrefixNone:
+		return "NONE"
+	}
+
+	return fmt.Sprintf("PrefixFormat(%d)", int(f))
+}
+
+// prefixFormat is the format set by SetPrefixFormat.
+var prefixFormat atomic.Int32 //nolint:gochecknoglobals // package-level setting
+
+// SetPrefixFormat sets the format of the call site prefix for the errors created after the call.
+func SetPrefixFormat(f PrefixFormat) {
+	prefixFormat.Store(int32(f))
+}
+
+// formatSite returns the call site in the current prefix format without braces,
+// empty if prefixes are disabled.
+func formatSite(function, file string, line int) string {
+	switch PrefixFormat(prefixFormat.Load()) {
+	case PrefixFunctionLine:
+		return shortFunction(function) + "." + strconv.Itoa(line)
+	case PrefixFunction:
+		return shortFunction(function)
+	case PrefixFileLine:
+		return trimmedFile(file) + ":" + strconv.Itoa(line)
+	case PrefixNone:
+	}
+
+	return ""
+}
+
+// shortFunction removes the package path from the function name:
+// "github.com/user/project/pkg.(*Type).Method" -> "pkg.(*Type).Method".
+func shortFunction(function string) string {
+	return function[strings.LastIndexByte(function, '/')+1:]
+}
+
+// trimmedFile keeps the last directory and the file name: "/home/user/project/pkg/file.go" -> "pkg/file.go".
+func trimmedFile(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx == -1 {
+		return file
+	}
+	if idx = strings.LastIndexByte(file[:idx], '/'); idx == -1 {
+		return file
+	}
+	return file[idx+1:]
+}
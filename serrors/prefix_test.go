@@ -0,0 +1,80 @@
+package serrors
+
+import (
+	"errors"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func wrapTwice() (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	err := Error(errors.New("failed"))
+	err = WithKind(err, KindInternal)
+	return Errorf("load: %w", err), line + 1
+}
+
+func wrapWithHelper(err error) (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	return Error(err), line + 1
+}
+
+func TestSameFunctionWrap(t *testing.T) {
+	err, wrapLine := wrapTwice()
+	require.Equal(t, "load: {serrors.wrapTwice."+strconv.Itoa(wrapLine)+"} failed", err.Error())
+
+	errHelper, _ := wrapWithHelper(errors.New("failed"))
+	errHelper, helperLine := wrapWithHelper(errHelper)
+	require.Equal(t, "{serrors.wrapWithHelper."+strconv.Itoa(helperLine)+"} failed", errHelper.Error())
+
+	// wrapping in another function adds a prefix
+	_, _, line, _ := runtime.Caller(0)
+	err = Error(err)
+	require.Equal(t, "{serrors.TestSameFunctionWrap."+strconv.Itoa(line+1)+"} "+
+		"load: {serrors.wrapTwice."+strconv.Itoa(wrapLine)+"} failed", err.Error())
+}
+
+func TestSetPrefixFormat(t *testing.T) {
+	defer SetPrefixFormat(PrefixFunctionLine)
+
+	tests := []struct {
+		format PrefixFormat
+		want   func(line int) string
+	}{
+		{
+			format: PrefixFunctionLine,
+			want:   func(line int) string { return "{serrors.TestSetPrefixFormat." + strconv.Itoa(line) + "} failed" },
+		},
+		{
+			format: PrefixFunction,
+			want:   func(int) string { return "{serrors.TestSetPrefixFormat} failed" },
+		},
+		{
+			format: PrefixFileLine,
+			want:   func(line int) string { return "{serrors/prefix_test.go:" + strconv.Itoa(line) + "} failed" },
+		},
+		{
+			format: PrefixNone,
+			want:   func(int) string { return "failed" },
+		},
+	}
+
+	for _, tt := range tests {
+		SetPrefixFormat(tt.format)
+
+		_, _, line, _ := runtime.Caller(0)
+		err := New("failed")
+		require.Equal(t, tt.want(line+1), err.Error(), tt.format.String())
+	}
+}
+
+func BenchmarkErrorf(b *testing.B) {
+	errBase := errors.New("base")
+
+	b.ReportAllocs()
+	for range b.N {
+		_ = Errorf("failed to load order %d: %w", 10, errBase)
+	}
+}
@@ -0,0 +1,31 @@
+package serrors
+
+// Sentinel is an error for package-level variables created by NewSentinel:
+//
+//	var ErrNotFound = serrors.NewSentinel("not found")
+//
+// Unlike New, a sentinel has no call site prefix: it gets the prefix of the site it is returned from
+// when it is wrapped with Error or Errorf, and the wrapped error still matches it with errors.Is:
+//
+//	func (r *Repo) Order(id int) (*Order, error) {
+//		...
+//		return nil, serrors.Error(ErrNotFound) // {repo.(*Repo).Order.25} not found
+//	}
+//
+//	errors.Is(err, ErrNotFound) // true
+//
+// Sentinels are compared by identity, like the errors of errors.New:
+// sentinels with the same message are different errors.
+type Sentinel struct {
+	msg string
+}
+
+// NewSentinel returns a sentinel with the message.
+func NewSentinel(msg string) *Sentinel {
+	return &Sentinel{msg: msg}
+}
+
+// Error implements error.
+func (s *Sentinel) Error() string {
+	return s.msg
+}
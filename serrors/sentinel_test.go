@@ -0,0 +1,45 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errSentinel = NewSentinel("not found")
+
+func returnSentinel() (error, int) {
+	_, _, line, _ := runtime.Caller(0)
+	return Error(errSentinel), line + 1
+}
+
+func TestSentinel(t *testing.T) {
+	require.Equal(t, "not found", errSentinel.Error())
+
+	err, line := returnSentinel()
+	require.Equal(t, "{serrors.returnSentinel."+strconv.Itoa(line)+"} not found", err.Error())
+	require.ErrorIs(t, err, errSentinel)
+	require.ErrorIs(t, fmt.Errorf("load: %w", err), errSentinel)
+	require.ErrorIs(t, Errorf("order %d: %w", 10, errSentinel), errSentinel)
+	require.NotErrorIs(t, err, NewSentinel("other"))
+
+	var s *Sentinel
+	require.ErrorAs(t, WithKind(err, KindNotFound), &s)
+	require.Same(t, errSentinel, s)
+	require.False(t, errors.Is(New("not found"), errSentinel))
+}
+
+func TestSentinel_Identity(t *testing.T) {
+	// sentinels with the same message, for example defined in different packages, don't match
+	other := NewSentinel("not found")
+	require.Equal(t, errSentinel.Error(), other.Error())
+	require.NotErrorIs(t, other, errSentinel)
+
+	err, _ := returnSentinel()
+	require.NotErrorIs(t, err, other)
+	require.NotErrorIs(t, fmt.Errorf("load: %w", other), errSentinel)
+}
@@ -0,0 +1,58 @@
+package serrors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapping(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	err := Error(errFirst)
+	require.ErrorIs(t, err, errFirst)
+	require.Equal(t, errFirst, errors.Unwrap(err))
+
+	_, _, line, _ := runtime.Caller(0)
+	err = Errorf("%w and %w", errFirst, errSecond)
+	require.ErrorIs(t, err, errFirst)
+	require.ErrorIs(t, err, errSecond)
+	require.Equal(t, "{serrors.TestWrapping."+strconv.Itoa(line+1)+"} first and second", err.Error())
+}
+
+func TestStackTrace(t *testing.T) {
+	err := New("no stack")
+	require.Empty(t, StackTrace(err))
+	require.Equal(t, err.Error(), fmt.Sprintf("%+v", err))
+
+	SetStackTrace(true)
+	defer SetStackTrace(false)
+
+	_, _, line, _ := runtime.Caller(0)
+	err = New("with stack")
+	line++
+
+	var st interface{ StackTrace() []uintptr }
+	require.ErrorAs(t, err, &st)
+	frame, _ := runtime.CallersFrames(st.StackTrace()).Next()
+	require.Equal(t, "github.com/n-r-w/ctxlog/serrors.TestStackTrace", frame.Function)
+	require.Equal(t, line, frame.Line)
+
+	// the stack is captured once per chain
+	wrapped := Errorf("wrapped: %w", err)
+	require.ErrorAs(t, wrapped, &st)
+	require.Empty(t, st.StackTrace())
+	require.Equal(t, StackTrace(err), StackTrace(wrapped))
+
+	formatted := fmt.Sprintf("%+v", wrapped)
+	require.True(t, strings.HasPrefix(formatted, wrapped.Error()+"\ngithub.com/n-r-w/ctxlog/serrors.TestStackTrace\n\t"))
+	require.Contains(t, formatted, "stack_test.go:"+strconv.Itoa(line)+"\n")
+	require.Equal(t, wrapped.Error(), fmt.Sprintf("%v", wrapped))
+	require.Equal(t, strconv.Quote(wrapped.Error()), fmt.Sprintf("%q", wrapped))
+}
@@ -0,0 +1,211 @@
+package ctxlog
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SourcePathFormat is the format of the file path in the source attribute.
+type SourcePathFormat int
+
+const (
+	// SourcePathTrimmed (default) keeps the last directory and the file name: "pkg/file.go".
+	SourcePathTrimmed SourcePathFormat = iota
+
+	// SourcePathFull is the absolute file path: "/home/user/project/pkg/file.go".
+	SourcePathFull
+
+	// SourcePathModule is the path relative to the module root: "pkg/file.go" for the packages of
+	// the main module and its dependencies, and the package import path otherwise: "net/http/server.go".
+	// Files of main packages are relative to the nearest directory with go.mod: "cmd/app/main.go".
+	SourcePathModule
+)
+
+// String returns the name of the source path format.
+func (f SourcePathFormat) String() string {
+	switch f {
+	case SourcePathTrimmed:
+		return "TRIMMED"
+	case SourcePathFull:
+		return "FULL"
+	case SourcePathModule:
+		return "MODULE"
+	}
+
+	return fmt.Sprintf("SourcePathFormat(%d)", int(f))
+}
+
+const defaultSourceKey = "source"
+
+// sourceFormat defines how the call source is added to records.
+type sourceFormat struct {
+	key      string
+	path     SourcePathFormat
+	function bool
+	group    bool
+}
+
+// attr returns the source attribute for the frame.
+// As a string: "path:line" or "path:line pkg.Func" if the function is enabled.
+// As a group: file, line and function.
+func (f sourceFormat) attr(frame runtime.Frame) slog.Attr {
+	file := f.filePath(frame)
+
+	if f.group {
+		return slog.Group(f.key,
+			slog.String("file", file),
+			slog.Int("line", frame.Line),
+			slog.String("function", frame.Function),
+		)
+	}
+
+	s := file + ":" + strconv.Itoa(frame.Line)
+	if f.function && frame.Function != "" {
+		s += " " + shortFunction(frame.Function)
+	}
+
+	return slog.String(f.key, s)
+}
+
+// sourceCache caches source attributes by program counter.
+// Lookups are lock-free; the map is copied on insert, which happens once per call site.
+type sourceCache struct {
+	mu    sync.Mutex
+	attrs atomic.Pointer[map[uintptr]slog.Attr]
+}
+
+// attr returns the source attribute for the program counter of a record.
+func (c *sourceCache) attr(pc uintptr, format sourceFormat) slog.Attr {
+	if attrs := c.attrs.Load(); attrs != nil {
+		if a, ok := (*attrs)[pc]; ok {
+			return a
+		}
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	a := format.attr(frame)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attrs.Store(copyWith(c.attrs.Load(), pc, a))
+
+	return a
+}
+
+func (f sourceFormat) filePath(frame runtime.Frame) string {
+	switch f.path {
+	case SourcePathFull:
+		return frame.File
+	case SourcePathModule:
+		return modulePath(frame)
+	case SourcePathTrimmed:
+	}
+
+	return trimmedFile(frame.File)
+}
+
+// trimmedFile keeps the last directory and the file name, see zapcore.EntryCaller.TrimmedPath.
+func trimmedFile(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx == -1 {
+		return file
+	}
+	// Find the penultimate separator.
+	idx = strings.LastIndexByte(file[:idx], '/')
+	if idx == -1 {
+		return file
+	}
+	return file[idx+1:]
+}
+
+// shortFunction removes the package path from the function name:
+// "github.com/user/project/pkg.(*Type).Method" -> "pkg.(*Type).Method".
+func shortFunction(function string) string {
+	if idx := strings.LastIndexByte(function, '/'); idx >= 0 {
+		return function[idx+1:]
+	}
+	return function
+}
+
+// modulePath returns the file path relative to the root of its module.
+func modulePath(frame runtime.Frame) string {
+	pkg := packagePath(frame.Function)
+	switch pkg {
+	case "":
+		return trimmedFile(frame.File)
+	case "main":
+		// the import path of a main package is not known
+		return mainModulePath(frame.File)
+	}
+
+	// external test packages are in the directory of the tested package
+	file := path.Join(strings.TrimSuffix(pkg, "_test"), path.Base(frame.File))
+	for _, mod := range buildModules() {
+		if rel, ok := strings.CutPrefix(file, mod+"/"); ok {
+			return rel
+		}
+	}
+
+	return file
+}
+
+// mainModulePath returns the path of a file of a main package relative to the root of the main module.
+// The root is the module path for binaries built with -trimpath and the nearest directory
+// with go.mod otherwise.
+func mainModulePath(file string) string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		if rel, ok := strings.CutPrefix(file, info.Main.Path+"/"); ok {
+			return rel
+		}
+	}
+
+	for dir := path.Dir(file); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if _, err := os.Stat(dir + "/go.mod"); err == nil {
+			return strings.TrimPrefix(file, dir+"/")
+		}
+	}
+
+	return trimmedFile(file)
+}
+
+// packagePath returns the import path of the function package:
+// "github.com/user/project/pkg.(*Type).Method" -> "github.com/user/project/pkg".
+func packagePath(function string) string {
+	slash := max(strings.LastIndexByte(function, '/'), 0)
+	dot := strings.IndexByte(function[slash:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+dot]
+}
+
+// buildModules returns the paths of the main module and its dependencies, longest first.
+var buildModules = sync.OnceValue(func() []string { //nolint:gochecknoglobals // singleton
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	mods := make([]string, 0, len(info.Deps)+1)
+	if info.Main.Path != "" {
+		mods = append(mods, info.Main.Path)
+	}
+	for _, dep := range info.Deps {
+		mods = append(mods, dep.Path)
+	}
+
+	// nested modules must be matched before their parents
+	slices.SortFunc(mods, func(a, b string) int { return len(b) - len(a) })
+
+	return mods
+})
@@ -0,0 +1,326 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// logSourceRecord logs a record and returns the file and line of the call.
+func logSourceRecord(logger *Logger) (string, int) {
+	_, file, line, _ := runtime.Caller(0)
+	logger.Info(context.Background(), "source message")
+	return file, line + 1
+}
+
+// TestLogger_SourceFormat verifies the source attribute options.
+func TestLogger_SourceFormat(t *testing.T) {
+	t.Parallel()
+
+	const function = "github.com/n-r-w/ctxlog.logSourceRecord"
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantKey string
+		want    func(file string, line int) any
+	}{
+		{
+			name:    "default",
+			wantKey: "source",
+			want: func(file string, line int) any {
+				return trimmedFile(file) + ":" + strconv.Itoa(line)
+			},
+		},
+		{
+			name:    "custom key",
+			opts:    []Option{WithSourceKey("src")},
+			wantKey: "src",
+			want: func(file string, line int) any {
+				return trimmedFile(file) + ":" + strconv.Itoa(line)
+			},
+		},
+		{
+			name:    "full path",
+			opts:    []Option{WithSourcePath(SourcePathFull)},
+			wantKey: "source",
+			want: func(file string, line int) any {
+				return file + ":" + strconv.Itoa(line)
+			},
+		},
+		{
+			name:    "module path with function",
+			opts:    []Option{WithSourcePath(SourcePathModule), WithSourceFunction(true)},
+			wantKey: "source",
+			want: func(_ string, line int) any {
+				return "source_test.go:" + strconv.Itoa(line) + " ctxlog.logSourceRecord"
+			},
+		},
+		{
+			name:    "group",
+			opts:    []Option{WithSourceGroup(true), WithSourcePath(SourcePathModule)},
+			wantKey: "source",
+			want: func(_ string, line int) any {
+				return map[string]any{
+					"file":     "source_test.go",
+					"line":     float64(line),
+					"function": function,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(append(tt.opts,
+				WithFormat(FormatJSON),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)...)
+			require.NoError(t, err)
+
+			file, line := logSourceRecord(logger)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.Equal(t, tt.want(file, line), record[tt.wantKey])
+		})
+	}
+}
+
+// logThroughHelper logs through a helper that overrides the stack frame skip.
+func logThroughHelper(ctx context.Context) {
+	// runtime.Callers, handler.Handle, slog.Logger.log, slog.Logger.Log,
+	// Logger.LogWithLevel, LogWithLevel, Info and logThroughHelper
+	const skip = 8
+	Info(SetSkipCallStack(ctx, skip), "source message")
+}
+
+// TestLogger_SourceCaller verifies that the source points at the caller for every logging path.
+func TestLogger_SourceCaller(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		log  func(ctx context.Context, logger *Logger) int
+	}{
+		{
+			name: "logger method",
+			log: func(ctx context.Context, logger *Logger) int {
+				_, _, line, _ := runtime.Caller(0)
+				logger.Info(ctx, "source message")
+				return line + 1
+			},
+		},
+		{
+			name: "context helper",
+			log: func(ctx context.Context, _ *Logger) int {
+				_, _, line, _ := runtime.Caller(0)
+				Warn(ctx, "source message")
+				return line + 1
+			},
+		},
+		{
+			name: "wrapper",
+			log: func(ctx context.Context, _ *Logger) int {
+				_, _, line, _ := runtime.Caller(0)
+				NewWrapper().Error(ctx, "source message")
+				return line + 1
+			},
+		},
+		{
+			name: "slog logger",
+			log: func(ctx context.Context, logger *Logger) int {
+				_, _, line, _ := runtime.Caller(0)
+				logger.InfoContext(ctx, "source message")
+				return line + 1
+			},
+		},
+		{
+			name: "skip from context",
+			log: func(ctx context.Context, _ *Logger) int {
+				_, _, line, _ := runtime.Caller(0)
+				logThroughHelper(ctx)
+				return line + 1
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(
+				WithFormat(FormatJSON),
+				WithSourcePath(SourcePathModule),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)
+			require.NoError(t, err)
+
+			line := tt.log(ToContext(context.Background(), logger), logger)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+			require.Equal(t, "source_test.go:"+strconv.Itoa(line), record["source"])
+		})
+	}
+}
+
+// TestLogger_SourceAllocs verifies that the source doesn't add allocations to a record.
+// It is not parallel because testing.AllocsPerRun counts the allocations of all goroutines.
+func TestLogger_SourceAllocs(t *testing.T) { //nolint:paralleltest // see above
+	if raceEnabled {
+		t.Skip("the race detector adds allocations")
+	}
+
+	newLogger := func(source bool) *Logger {
+		logger, err := New(WithEnvType(EnvDevelopment), WithFormat(FormatJSON), WithSource(source),
+			WithOutputPaths(os.DevNull))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = logger.Close(context.Background()) })
+		return logger
+	}
+
+	const runs = 100
+	ctx := context.Background()
+
+	withSource := newLogger(true)
+	withoutSource := newLogger(false)
+
+	want := testing.AllocsPerRun(runs, func() {
+		withoutSource.Info(ctx, "message", "key", "value")
+	})
+	got := testing.AllocsPerRun(runs, func() {
+		withSource.Info(ctx, "message", "key", "value")
+	})
+	require.InDelta(t, want, got, 0)
+}
+
+// TestModulePath verifies module-relative paths of packages, including main packages.
+func TestModulePath(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0o600))
+
+	tests := []struct {
+		name  string
+		frame runtime.Frame
+		want  string
+	}{
+		{
+			name: "standard library",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "net/http.(*Server).Serve",
+				File:     "/usr/local/go/src/net/http/server.go",
+			},
+			want: "net/http/server.go",
+		},
+		{
+			name: "dependency",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "github.com/stretchr/testify/require.Equal",
+				File:     "/go/pkg/mod/github.com/stretchr/testify@v1.11.1/require/require.go",
+			},
+			want: "require/require.go",
+		},
+		{
+			name: "main module subpackage",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "github.com/n-r-w/ctxlog/serrors.Errorf",
+				File:     "/src/ctxlog/serrors/error.go",
+			},
+			want: "serrors/error.go",
+		},
+		{
+			name: "external test package",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "github.com/n-r-w/ctxlog/serrors_test.TestErrorf",
+				File:     "/src/ctxlog/serrors/error_test.go",
+			},
+			want: "serrors/error_test.go",
+		},
+		{
+			name: "main package",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "main.main",
+				File:     filepath.ToSlash(filepath.Join(root, "cmd", "server", "main.go")),
+			},
+			want: "cmd/server/main.go",
+		},
+		{
+			name: "main package built with trimpath",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "main.run",
+				File:     "github.com/n-r-w/ctxlog/cmd/server/main.go",
+			},
+			want: "cmd/server/main.go",
+		},
+		{
+			name: "main package outside a module",
+			frame: runtime.Frame{ //nolint:exhaustruct // only file and function are needed
+				Function: "main.main",
+				File:     "/nonexistent/server/main.go",
+			},
+			want: "server/main.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, modulePath(tt.frame))
+		})
+	}
+}
+
+func BenchmarkLogger_Source(b *testing.B) {
+	for _, source := range []bool{false, true} {
+		b.Run("source="+strconv.FormatBool(source), func(b *testing.B) {
+			logger, err := New(WithEnvType(EnvDevelopment), WithFormat(FormatJSON), WithSource(source),
+				WithOutputPaths(os.DevNull))
+			require.NoError(b, err)
+			defer func() { _ = logger.Close(context.Background()) }()
+
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				logger.Info(ctx, "message", "key", "value")
+			}
+		})
+	}
+}
+
+func BenchmarkInfo_ContextHelper(b *testing.B) {
+	logger, err := New(WithEnvType(EnvDevelopment), WithFormat(FormatJSON), WithOutputPaths(os.DevNull))
+	require.NoError(b, err)
+	defer func() { _ = logger.Close(context.Background()) }()
+
+	ctx := ToContext(context.Background(), logger)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		Info(ctx, "message", "key", "value")
+	}
+}
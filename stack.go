@@ -0,0 +1,113 @@
+package ctxlog
+
+import (
+	"log/slog"
+	"runtime"
+	"sync"
+
+	"github.com/n-r-w/ctxlog/serrors"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxStackFrames is the maximum number of frames in a captured stack trace.
+const maxStackFrames = 128
+
+// recordStack returns the stack trace of the record: the stack of the first error attribute
+// that carries one, or the current stack starting at the record call site.
+func recordStack(r slog.Record) string {
+	var pcs []uintptr
+	r.Attrs(func(a slog.Attr) bool {
+		if err := attrError(a); err != nil {
+			pcs = serrors.StackTrace(err)
+		}
+		return len(pcs) == 0
+	})
+	if len(pcs) > 0 {
+		return serrors.FormatStack(pcs)
+	}
+
+	var buf [maxStackFrames]uintptr
+	// skip runtime.Callers, recordStack and handler.Handle
+	n := runtime.Callers(3, buf[:]) //nolint:mnd // see above
+	stack := buf[:n]
+	for i, pc := range stack {
+		if pc == r.PC {
+			stack = stack[i:]
+			break
+		}
+	}
+
+	return serrors.FormatStack(stack)
+}
+
+// entryStack is the stack trace of a record passed from the handler to stackCore.
+type entryStack string
+
+// stackCore moves the entryStack field of a record to the entry stack,
+// so every encoder writes it in its own way under the StacktraceKey.
+type stackCore struct {
+	zapcore.Core
+}
+
+var _ zapcore.Core = (*stackCore)(nil)
+
+func newStackCore(core zapcore.Core) zapcore.Core {
+	return &stackCore{Core: core}
+}
+
+// With adds structured context to the core.
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return newStackCore(c.Core.With(fields))
+}
+
+// Check checks the entry with the wrapped core and defers the write to stackWriter,
+// because the stack is known only when the fields are written.
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := c.Core.Check(ent, nil)
+	if inner == nil {
+		return ce
+	}
+
+	w, _ := _stackWriterPool.Get().(*stackWriter)
+	w.Core = c.Core
+	w.ce = inner
+	return ce.AddCore(ent, w)
+}
+
+// stackWriter writes a checked entry of the wrapped core with the stack of the record.
+type stackWriter struct {
+	zapcore.Core
+
+	ce *zapcore.CheckedEntry
+}
+
+var _stackWriterPool = sync.Pool{ //nolint:gochecknoglobals // singleton
+	New: func() any { return &stackWriter{} }, //nolint:exhaustruct // set on use
+}
+
+// Write sets the entry stack and writes the checked entry.
+func (w *stackWriter) Write(_ zapcore.Entry, fields []zapcore.Field) error {
+	for i := range fields {
+		if stack, ok := fields[i].Interface.(entryStack); ok {
+			w.ce.Entry.Stack = string(stack)
+			fields = trimEmptyNamespaces(append(fields[:i:i], fields[i+1:]...))
+			break
+		}
+	}
+
+	w.ce.Write(fields...)
+
+	w.Core, w.ce = nil, nil
+	_stackWriterPool.Put(w)
+	return nil
+}
+
+// trimEmptyNamespaces removes the trailing namespaces that have no fields.
+// zapslog opens the groups of WithGroup before the first record attribute, even if it is
+// the stack or the trace fields that are moved out of the record, which leaves an empty group.
+func trimEmptyNamespaces(fields []zapcore.Field) []zapcore.Field {
+	for len(fields) > 0 && fields[len(fields)-1].Type == zapcore.NamespaceType {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
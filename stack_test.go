@@ -0,0 +1,188 @@
+package ctxlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// logStackRecord logs a record and returns the line of the call.
+func logStackRecord(logger *Logger, level slog.Level, attrs ...any) int {
+	_, _, line, _ := runtime.Caller(0)
+	logger.LogWithLevel(context.Background(), level, "stack message", defaultSkipCallStack-1, attrs...)
+	return line + 1
+}
+
+// stackError is an error that carries the stack of its creation.
+type stackError struct {
+	pcs []uintptr
+}
+
+func (e stackError) Error() string { return "stack error" }
+
+func (e stackError) StackTrace() []uintptr { return e.pcs }
+
+// newStackError returns a stackError and the line of the stack capture.
+func newStackError() (stackError, int) {
+	pcs := make([]uintptr, maxStackFrames)
+	_, _, line, _ := runtime.Caller(0)
+	n := runtime.Callers(1, pcs)
+	return stackError{pcs: pcs[:n]}, line + 1
+}
+
+// TestLogger_Stacktrace verifies the stack traces of records.
+func TestLogger_Stacktrace(t *testing.T) {
+	t.Parallel()
+
+	errWithStack, errLine := newStackError()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		level    slog.Level
+		attrs    []any
+		key      string
+		wantFunc string // first frame function, empty if no stack is expected
+		wantLine int    // first frame line, 0 for the line of the logging call
+	}{
+		{
+			name:     "error level",
+			level:    slog.LevelError,
+			key:      "stacktrace",
+			wantFunc: "github.com/n-r-w/ctxlog.logStackRecord",
+		},
+		{
+			name:  "below the default level",
+			level: slog.LevelWarn,
+			key:   "stacktrace",
+		},
+		{
+			name:     "custom level",
+			opts:     []Option{WithStacktraceLevel(slog.LevelInfo)},
+			level:    slog.LevelInfo,
+			key:      "stacktrace",
+			wantFunc: "github.com/n-r-w/ctxlog.logStackRecord",
+		},
+		{
+			name:  "disabled",
+			opts:  []Option{WithStacktraceLevel(nil)},
+			level: slog.LevelError,
+			key:   "stacktrace",
+		},
+		{
+			name:     "error with stack",
+			level:    slog.LevelError,
+			attrs:    []any{slog.Any("error", errWithStack)},
+			key:      "stacktrace",
+			wantFunc: "github.com/n-r-w/ctxlog.newStackError",
+			wantLine: errLine,
+		},
+		{
+			name:     "wrapped error with stack",
+			level:    slog.LevelError,
+			attrs:    []any{"error", errors.Join(errors.New("other"), errWithStack)},
+			key:      "stacktrace",
+			wantFunc: "github.com/n-r-w/ctxlog.newStackError",
+			wantLine: errLine,
+		},
+		{
+			name:     "preset key",
+			opts:     []Option{WithFormatPreset(PresetECS)},
+			level:    slog.LevelError,
+			key:      "error.stack_trace",
+			wantFunc: "github.com/n-r-w/ctxlog.logStackRecord",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+			logger, err := New(append(tt.opts,
+				WithFormat(FormatJSON),
+				WithTesting(t),
+				WithTestBuffer(buffer),
+			)...)
+			require.NoError(t, err)
+
+			line := logStackRecord(logger.WithGroup("group"), tt.level, tt.attrs...)
+			require.NoError(t, logger.Sync())
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buffer.String())), &record))
+
+			if tt.wantFunc == "" {
+				require.NotContains(t, record, tt.key)
+				return
+			}
+			if tt.wantLine != 0 {
+				line = tt.wantLine
+			}
+
+			stack, ok := record[tt.key].(string)
+			require.True(t, ok, "stack trace is missing: %v", record)
+
+			frames := strings.Split(stack, "\n")
+			require.GreaterOrEqual(t, len(frames), 2)
+			require.Equal(t, tt.wantFunc, frames[0])
+			require.True(t, strings.HasSuffix(frames[1], "stack_test.go:"+strconv.Itoa(line)), frames[1])
+		})
+	}
+}
+
+// TestLogger_StacktraceGroup verifies that the stack trace doesn't open an empty group.
+func TestLogger_StacktraceGroup(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(WithFormat(FormatJSON), WithSource(false), WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logger.WithGroup("g").Error(ctx, "no attrs")
+	logger.WithGroup("g").Error(ctx, "with attrs", "key", "value")
+	require.NoError(t, logger.Sync())
+
+	lines := buffer.Lines()
+	require.Len(t, lines, 2)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.NotContains(t, record, "g")
+	require.Contains(t, record, "stacktrace")
+
+	record = nil
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, map[string]any{"key": "value"}, record["g"])
+	require.Contains(t, record, "stacktrace")
+}
+
+// TestLogger_StacktraceConsole verifies that the console format prints the stack on separate lines.
+func TestLogger_StacktraceConsole(t *testing.T) {
+	t.Parallel()
+
+	buffer := &zaptest.Buffer{} //nolint:exhaustruct // default options
+
+	logger, err := New(WithTesting(t), WithTestBuffer(buffer))
+	require.NoError(t, err)
+
+	line := logStackRecord(logger, slog.LevelError)
+	require.NoError(t, logger.Sync())
+
+	lines := buffer.Lines()
+	require.GreaterOrEqual(t, len(lines), 3)
+	require.Contains(t, lines[0], "stack message")
+	require.Equal(t, "github.com/n-r-w/ctxlog.logStackRecord", lines[1])
+	require.True(t, strings.HasSuffix(lines[2], "stack_test.go:"+strconv.Itoa(line)), lines[2])
+}